@@ -54,6 +54,10 @@ const (
 	flagDstClientID             = "dst-client-id"
 	flagSrcConnID               = "src-connection-id"
 	flagDstConnID               = "dst-connection-id"
+	flagSpecyBackfillFrom       = "specy-backfill-from"
+	flagSpecySkipBackfill       = "specy-skip-backfill"
+	flagSpecyAdminToken         = "admin-token"
+	flagSpecyFromHeight         = "from-height"
 )
 
 const (
@@ -356,6 +360,49 @@ func initBlockFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+func specyBackfillFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Int64(flagSpecyBackfillFrom, 0, "replay specy task events on the target chain from this height before starting; by default, from the height following the last one processed before the restart, if any")
+	if err := v.BindPFlag(flagSpecyBackfillFrom, cmd.Flags().Lookup(flagSpecyBackfillFrom)); err != nil {
+		panic(err)
+	}
+	cmd.Flags().Bool(flagSpecySkipBackfill, false, "do not replay the specy task events emitted since the last height processed before the restart")
+	if err := v.BindPFlag(flagSpecySkipBackfill, cmd.Flags().Lookup(flagSpecySkipBackfill)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func specyAdminFlags(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().String(flagDebugAddr, "", "address of the debug server of the running relayer. By default, will be the api-listen-addr parameter in the global config.")
+	cmd.Flags().String(flagSpecyAdminToken, "", "bearer token of the specy admin API. By default, will be the admin_token parameter in the specy config.")
+	if err := v.BindPFlag(flagDebugAddr, cmd.Flags().Lookup(flagDebugAddr)); err != nil {
+		panic(err)
+	}
+	if err := v.BindPFlag(flagSpecyAdminToken, cmd.Flags().Lookup(flagSpecyAdminToken)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func specyHistoryLimitFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Int(flagLimit, 20, "number of most recent executions to show; 0 shows the whole history")
+	if err := v.BindPFlag(flagLimit, cmd.Flags().Lookup(flagLimit)); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func specyFromHeightFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().Int64(flagSpecyFromHeight, 0, "height to replay the specy task events from, at or before the creation of the tasks to list")
+	if err := v.BindPFlag(flagSpecyFromHeight, cmd.Flags().Lookup(flagSpecyFromHeight)); err != nil {
+		panic(err)
+	}
+	if err := cmd.MarkFlagRequired(flagSpecyFromHeight); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
 func flushIntervalFlag(v *viper.Viper, cmd *cobra.Command) *cobra.Command {
 	cmd.Flags().DurationP(flagFlushInterval, "i", relayer.DefaultFlushInterval, "how frequently should a flush routine be run")
 	if err := v.BindPFlag(flagFlushInterval, cmd.Flags().Lookup(flagFlushInterval)); err != nil {
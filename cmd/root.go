@@ -122,6 +122,7 @@ func NewRootCmd(log *zap.Logger) *cobra.Command {
 		transactionCmd(a),
 		queryCmd(a),
 		startCmd(a),
+		specyCmd(a),
 		lineBreakCommand(),
 		getVersionCmd(a),
 	)
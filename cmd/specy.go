@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	specyconfig "github.com/cosmos/relayer/v2/specy/config"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// specyCmd represents the specy command
+func specyCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "specy",
+		Short: "Specy task scheduler commands",
+		Long: strings.TrimSpace(`Commands to operate the specy tasks scheduled by a running relayer, through the admin API
+of its debug server, and to query the specy tasks registered on the target chain.`),
+	}
+
+	cmd.AddCommand(
+		specyTasksCmd(a),
+	)
+
+	return cmd
+}
+
+func specyTasksCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tasks",
+		Aliases: []string{"t"},
+		Short:   "Manage the specy tasks",
+	}
+
+	cmd.AddCommand(
+		specyTasksListCmd(a),
+		specyTasksShowCmd(a),
+		specyTaskActionCmd(a, "trigger", "Execute a task right away on the running relayer", "Triggered"),
+		specyTaskActionCmd(a, "pause", "Pause a task on the running relayer", "Paused"),
+		specyTaskActionCmd(a, "resume", "Resume a paused task on the running relayer", "Resumed"),
+		lineBreakCommand(),
+		specyTasksOnchainCmd(a),
+	)
+
+	return cmd
+}
+
+func specyTasksListCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"l"},
+		Short:   "List the tasks registered on the running relayer with their next activation",
+		Args:    withUsage(cobra.NoArgs),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s specy tasks list
+$ %s specy tasks list --debug-addr localhost:5183 --json`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newSpecyAdminClient(a, cmd)
+			if err != nil {
+				return err
+			}
+
+			var statuses []specy.TaskStatus
+			if err := client.do(cmd.Context(), http.MethodGet, "tasks", &statuses); err != nil {
+				return err
+			}
+
+			return printSpecyOutput(cmd, statuses, func(w io.Writer) {
+				if len(statuses) == 0 {
+					fmt.Fprintln(cmd.ErrOrStderr(), "no specy tasks registered")
+					return
+				}
+				for _, status := range statuses {
+					printSpecyTaskStatus(w, status)
+				}
+			})
+		},
+	}
+	return yamlFlag(a.viper, jsonFlag(a.viper, specyAdminFlags(a.viper, cmd)))
+}
+
+func specyTasksShowCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "show task_hash",
+		Aliases: []string{"s"},
+		Short:   "Show a task registered on the running relayer with its most recent executions",
+		Args:    withUsage(cobra.ExactArgs(1)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s specy tasks show 5ad0c5b1
+$ %s specy tasks show 5ad0c5b1 --limit 100 --yaml`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, err := cmd.Flags().GetInt(flagLimit)
+			if err != nil {
+				return err
+			}
+
+			client, err := newSpecyAdminClient(a, cmd)
+			if err != nil {
+				return err
+			}
+
+			var res struct {
+				specy.TaskStatus
+				Executions []*specy.ExecutionRecord `json:"executions"`
+			}
+			path := fmt.Sprintf("tasks/%s?limit=%d", url.PathEscape(args[0]), limit)
+			if err := client.do(cmd.Context(), http.MethodGet, path, &res); err != nil {
+				return err
+			}
+
+			return printSpecyOutput(cmd, res, func(w io.Writer) {
+				printSpecyTaskStatus(w, res.TaskStatus)
+				for _, record := range res.Executions {
+					printSpecyExecutionRecord(w, record)
+				}
+			})
+		},
+	}
+	return yamlFlag(a.viper, jsonFlag(a.viper, specyHistoryLimitFlag(a.viper, specyAdminFlags(a.viper, cmd))))
+}
+
+// specyTaskActionCmd returns the command applying an action of the admin API to a task.
+func specyTaskActionCmd(a *appState, action, short, done string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   action + " task_hash",
+		Short: short,
+		Args:  withUsage(cobra.ExactArgs(1)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s specy tasks %s 5ad0c5b1`,
+			appName, action,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newSpecyAdminClient(a, cmd)
+			if err != nil {
+				return err
+			}
+
+			var status specy.TaskStatus
+			path := fmt.Sprintf("tasks/%s/%s", url.PathEscape(args[0]), action)
+			if err := client.do(cmd.Context(), http.MethodPost, path, &status); err != nil {
+				return err
+			}
+
+			return printSpecyOutput(cmd, status, func(w io.Writer) {
+				fmt.Fprintf(w, "%s task %s\n", done, args[0])
+				printSpecyTaskStatus(w, status)
+			})
+		},
+	}
+	return yamlFlag(a.viper, jsonFlag(a.viper, specyAdminFlags(a.viper, cmd)))
+}
+
+func specyTasksOnchainCmd(a *appState) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "onchain [chain_id]",
+		Aliases: []string{"o"},
+		Short:   "List the tasks registered on the target chain",
+		Long: strings.TrimSpace(`List the specy tasks live on the chain, by replaying the specy task events emitted since
+the given height, which is required: every block from it up to the latest one is queried, so it should be
+close to the creation of the oldest task to list. The chain defaults to the target chain of the specy config.`),
+		Args: withUsage(cobra.MaximumNArgs(1)),
+		Example: strings.TrimSpace(fmt.Sprintf(`
+$ %s specy tasks onchain --from-height 120000
+$ %s specy tasks onchain specy-1 --from-height 120000 --json`,
+			appName, appName,
+		)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fromHeight, err := cmd.Flags().GetInt64(flagSpecyFromHeight)
+			if err != nil {
+				return err
+			}
+			if fromHeight <= 0 {
+				return fmt.Errorf("--%s must be a positive height", flagSpecyFromHeight)
+			}
+
+			chainID := specyconfig.Config.TargetChainId
+			if len(args) > 0 {
+				chainID = args[0]
+			}
+			chains, err := a.config.Chains.Gets(chainID)
+			if err != nil {
+				return err
+			}
+			provider, err := specyTargetProvider(chains, chainID)
+			if err != nil {
+				return err
+			}
+
+			if err := setSpecyEventSchema(); err != nil {
+				return err
+			}
+			log := a.log.With(zap.String("chain_id", chainID))
+			taskSet, _, err := provider.ReplaySpecyTasks(cmd.Context(), log, fromHeight)
+			if err != nil {
+				return err
+			}
+			for taskHash, err := range taskSet.Rejected() {
+				log.Warn("Skipping specy task with an invalid task event", zap.String("task_hash", taskHash), zap.Error(err))
+			}
+
+			tasks := taskSet.Tasks()
+			return printSpecyOutput(cmd, tasks, func(w io.Writer) {
+				if len(tasks) == 0 {
+					fmt.Fprintln(cmd.ErrOrStderr(), "no specy tasks registered on chain")
+					return
+				}
+				for _, task := range tasks {
+					fmt.Fprintf(w, "%s: %s -> creator(%s) schedule(%s) paused(%s)\n",
+						task.TaskHash, task.TaskName, task.Creator, specyTaskSchedule(task), specyCheck(task.Paused))
+				}
+			})
+		},
+	}
+	return yamlFlag(a.viper, jsonFlag(a.viper, specyFromHeightFlag(a.viper, cmd)))
+}
+
+// specyAdminClient calls the specy admin API served by the debug server of a running relayer.
+type specyAdminClient struct {
+	baseURL string
+	token   string
+}
+
+// newSpecyAdminClient returns the client of the admin API at the debug address and with the token of the flags,
+// which default to the api-listen-addr of the global config and to the admin token of the specy config.
+func newSpecyAdminClient(a *appState, cmd *cobra.Command) (*specyAdminClient, error) {
+	addr, err := cmd.Flags().GetString(flagDebugAddr)
+	if err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		addr = a.config.Global.APIListenPort
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("no debug server address, pass --%s", flagDebugAddr)
+	}
+
+	token, err := cmd.Flags().GetString(flagSpecyAdminToken)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		token = specyconfig.Config.AdminToken
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no specy admin token, pass --%s or set admin_token in the specy config", flagSpecyAdminToken)
+	}
+
+	baseURL := addr
+	if !strings.Contains(addr, "://") {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid debug server address %q: %w", addr, err)
+		}
+		if host == "" {
+			host = "localhost"
+		}
+		baseURL = "http://" + net.JoinHostPort(host, port)
+	}
+	return &specyAdminClient{baseURL: strings.TrimSuffix(baseURL, "/"), token: token}, nil
+}
+
+// do sends the request to the admin API path and decodes the response into out.
+func (c *specyAdminClient) do(ctx context.Context, method, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+specy.AdminPathPrefix+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the specy admin API of the relayer: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil || body.Error == "" {
+			return fmt.Errorf("specy admin API returned %s", res.Status)
+		}
+		return fmt.Errorf("specy admin API returned %s: %s", res.Status, body.Error)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// printSpecyOutput prints v in json or yaml when requested by the flags, with the json field names,
+// and as text otherwise.
+func printSpecyOutput(cmd *cobra.Command, v any, text func(w io.Writer)) error {
+	jsn, err := cmd.Flags().GetBool(flagJSON)
+	if err != nil {
+		return err
+	}
+
+	yml, err := cmd.Flags().GetBool(flagYAML)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case yml && jsn:
+		return fmt.Errorf("can't pass both --json and --yaml, must pick one")
+	case yml:
+		bz, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var doc any
+		if err := yaml.Unmarshal(bz, &doc); err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), string(out))
+	case jsn:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+	default:
+		text(cmd.OutOrStdout())
+	}
+	return nil
+}
+
+func printSpecyTaskStatus(w io.Writer, status specy.TaskStatus) {
+	next := "none"
+	switch {
+	case status.NextFireTime != nil:
+		next = status.NextFireTime.Format(time.RFC3339)
+	case status.NextFireHeight > 0:
+		next = fmt.Sprintf("height %d", status.NextFireHeight)
+	}
+	last := "never"
+	if status.LastRun != nil {
+		last = status.LastRun.Format(time.RFC3339)
+	}
+
+	task := status.Task
+	fmt.Fprintf(w, "%s: %s -> schedule(%s) owned(%s) paused(%s) next(%s) last(%s)\n",
+		task.TaskHash, task.TaskName, specyTaskSchedule(task), specyCheck(status.Owned), specyCheck(task.Paused), next, last)
+}
+
+func printSpecyExecutionRecord(w io.Writer, record *specy.ExecutionRecord) {
+	fmt.Fprintf(w, "  %s %s %s", record.FinishedAt.Format(time.RFC3339), record.ExecutionID, record.Status)
+	if record.TxHash != "" {
+		fmt.Fprintf(w, " tx(%s code %d)", record.TxHash, record.TxCode)
+	}
+	if f := record.EngineFailure; f != nil {
+		fmt.Fprintf(w, " engine(%s after %d attempts)", f.Code, f.Attempts)
+	}
+	if record.Error != "" {
+		fmt.Fprintf(w, " error(%s)", record.Error)
+	}
+	fmt.Fprintln(w)
+}
+
+// specyTaskSchedule describes the trigger condition of the task.
+func specyTaskSchedule(task *specy.Task) string {
+	c := task.Condition
+	switch c.IntervalType {
+	case specy.IntervalTypeCron:
+		return fmt.Sprintf("%s %q", c.IntervalType, c.CronExpression)
+	case specy.IntervalTypeTimeInterval, specy.IntervalTypeEveryNBlocks:
+		return fmt.Sprintf("%s %d", c.IntervalType, c.Interval)
+	case specy.IntervalTypeOnEvent:
+		return fmt.Sprintf("%s %s", c.IntervalType, c.EventType)
+	case specy.IntervalTypeDependent:
+		return fmt.Sprintf("%s %s", c.IntervalType, strings.Join(task.DependsOn, ","))
+	default:
+		return c.IntervalType
+	}
+}
+
+func specyCheck(ok bool) string {
+	if ok {
+		return check
+	}
+	return xIcon
+}
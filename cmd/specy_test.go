@@ -0,0 +1,55 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/internal/relayertest"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSpecyTasks_AdminAPI(t *testing.T) {
+	t.Parallel()
+
+	executor := func(context.Context, *specy.Task, specy.Trigger, *specy.ExecutionRecord) error { return nil }
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, executor)
+	task := specy.NewTask("5ad0c5b1", "payout", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+	require.NoError(t, scheduler.Register(task))
+
+	srv := httptest.NewServer(specy.NewAdminHandler(zap.NewNop(), scheduler, "secret"))
+	defer srv.Close()
+
+	sys := relayertest.NewSystem(t)
+	_ = sys.MustRun(t, "config", "init")
+	admin := []string{"--debug-addr", srv.URL, "--admin-token", "secret"}
+
+	res := sys.MustRun(t, append([]string{"specy", "tasks", "list"}, admin...)...)
+	require.Regexp(t, `^5ad0c5b1: payout -> schedule\(every_block\) owned\(✔\) paused\(✘\)`, res.Stdout.String())
+
+	res = sys.MustRun(t, append([]string{"specy", "tasks", "pause", "5ad0c5b1", "--json"}, admin...)...)
+	var status specy.TaskStatus
+	require.NoError(t, json.Unmarshal(res.Stdout.Bytes(), &status))
+	require.Equal(t, "5ad0c5b1", status.Task.TaskHash)
+	require.True(t, status.Task.Paused)
+
+	res = sys.MustRun(t, append([]string{"specy", "tasks", "show", "5ad0c5b1", "--yaml"}, admin...)...)
+	var shown map[string]any
+	require.NoError(t, yaml.Unmarshal(res.Stdout.Bytes(), &shown))
+	require.Equal(t, true, shown["owned"])
+	require.Equal(t, "5ad0c5b1", shown["task"].(map[string]any)["task_hash"])
+	require.Empty(t, shown["executions"])
+
+	// the errors of the admin API are reported
+	res = sys.Run(zaptest.NewLogger(t), append([]string{"specy", "tasks", "resume", "unknown"}, admin...)...)
+	require.ErrorContains(t, res.Err, "unknown task unknown")
+
+	res = sys.Run(zaptest.NewLogger(t), "specy", "tasks", "list", "--debug-addr", srv.URL, "--admin-token", "wrong")
+	require.ErrorContains(t, res.Err, "401")
+}
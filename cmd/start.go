@@ -21,13 +21,18 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cosmos/relayer/v2/internal/relaydebug"
 	"github.com/cosmos/relayer/v2/relayer"
 	"github.com/cosmos/relayer/v2/relayer/chains/cosmos"
 	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/cosmos/relayer/v2/specy"
+	specyconfig "github.com/cosmos/relayer/v2/specy/config"
 	specyexecutor "github.com/cosmos/relayer/v2/specy/executor"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -95,6 +100,7 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 			}
 
 			var prometheusMetrics *processor.PrometheusMetrics
+			var debugLn net.Listener
 
 			debugAddr := a.config.Global.APIListenPort
 
@@ -110,15 +116,12 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 			if debugAddr == "" {
 				a.log.Info("Skipping debug server due to empty debug address flag")
 			} else {
-				ln, err := net.Listen("tcp", debugAddr)
+				debugLn, err = net.Listen("tcp", debugAddr)
 				if err != nil {
 					a.log.Error("Failed to listen on debug address. If you have another relayer process open, use --" + flagDebugAddr + " to pick a different address.")
 					return fmt.Errorf("failed to listen on debug address %q: %w", debugAddr, err)
 				}
-				log := a.log.With(zap.String("sys", "debughttp"))
-				log.Info("Debug server listening", zap.String("addr", debugAddr))
 				prometheusMetrics = processor.NewPrometheusMetrics()
-				relaydebug.StartDebugServer(cmd.Context(), log, ln, prometheusMetrics.Registry)
 				for _, chain := range chains {
 					if ccp, ok := chain.ChainProvider.(*cosmos.CosmosProvider); ok {
 						ccp.SetMetrics(prometheusMetrics)
@@ -145,8 +148,81 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 				return err
 			}
 
+			specyBackfillFrom, err := cmd.Flags().GetInt64(flagSpecyBackfillFrom)
+			if err != nil {
+				return err
+			}
+			specySkipBackfill, err := cmd.Flags().GetBool(flagSpecySkipBackfill)
+			if err != nil {
+				return err
+			}
+
+			// the guards of the tasks are checked against the specy target chain,
+			// including the ones of the activations caught up when the tasks are loaded
+			var guardQuerier specy.GuardQuerier
+			specyProvider, specyProviderErr := specyTargetProvider(chains, specyconfig.Config.TargetChainId)
+			if specyProviderErr == nil {
+				guardQuerier = cosmos.NewSpecyGuardQuerier(specyProvider)
+			} else {
+				a.log.Warn("Specy task guards cannot be checked", zap.Error(specyProviderErr))
+			}
+
 			// init specy network environment
-			initSpecyNetwork(cmd.Context())
+			specyScheduler, enginePool, err := initSpecyNetwork(cmd.Context(), a.log, a.homePath, prometheusMetrics, guardQuerier)
+			if err != nil {
+				return err
+			}
+
+			if debugLn != nil {
+				// the debug server also serves the state of the engine nodes,
+				// and the specy admin API when an admin token is configured
+				handlers := map[string]http.Handler{
+					specyexecutor.EngineStatusPath: specyexecutor.NewEngineStatusHandler(enginePool),
+				}
+				if token := specyconfig.Config.AdminToken; token != "" {
+					handlers[specy.AdminPathPrefix] = specy.NewAdminHandler(a.log.With(zap.String("sys", "specyadmin")), specyScheduler, token)
+				}
+				log := a.log.With(zap.String("sys", "debughttp"))
+				log.Info("Debug server listening", zap.String("addr", debugAddr))
+				relaydebug.StartDebugServer(cmd.Context(), log, debugLn, prometheusMetrics.Registry, handlers)
+			}
+
+			// without an explicit height, the events emitted while the relayer was down are replayed
+			// from the last height processed before the restart
+			backfillFrom := specyBackfillFrom
+			resumed := false
+			if backfillFrom <= 0 && !specySkipBackfill {
+				if last := specyScheduler.LastProcessedHeight(); last > 0 {
+					if specyProvider != nil {
+						backfillFrom, resumed = last+1, true
+					} else {
+						a.log.Warn("Specy task events emitted since the last processed height cannot be replayed", zap.Int64("last_height", last))
+					}
+				}
+			}
+			if backfillFrom > 0 {
+				if specyProvider == nil {
+					return specyProviderErr
+				}
+				_, err := specyProvider.BackfillSpecyTasks(
+					cmd.Context(),
+					a.log.With(zap.String("chain_id", specyconfig.Config.TargetChainId)),
+					specyScheduler,
+					backfillFrom,
+				)
+				switch {
+				case err == nil:
+				case resumed && !errors.Is(err, context.Canceled):
+					// the relayer still starts with the tasks of its store when the missed events cannot be replayed
+					a.log.Warn(
+						"Failed to replay specy task events emitted since the last processed height",
+						zap.Int64("from_height", backfillFrom),
+						zap.Error(err),
+					)
+				default:
+					return err
+				}
+			}
 
 			rlyErrCh := relayer.StartRelayer(
 				cmd.Context(),
@@ -161,6 +237,7 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 				processorType,
 				initialBlockHistory,
 				prometheusMetrics,
+				specyScheduler,
 			)
 
 			// Block until the error channel sends a message.
@@ -184,6 +261,7 @@ $ %s start demo-path2 --max-tx-size 10`, appName, appName, appName, appName)),
 	cmd = initBlockFlag(a.viper, cmd)
 	cmd = flushIntervalFlag(a.viper, cmd)
 	cmd = memoFlag(a.viper, cmd)
+	cmd = specyBackfillFlag(a.viper, cmd)
 	return cmd
 }
 
@@ -212,6 +290,157 @@ func GetStartOptions(cmd *cobra.Command) (uint64, uint64, error) {
 	return txSize * MB, msgLen, nil
 }
 
-func initSpecyNetwork(ctx context.Context) {
-	specyexecutor.ConnectSpecyEngineWithHeartbeat(ctx)
+// initSpecyNetwork connects to the specy engine and returns the task scheduler,
+// with the tasks persisted under the relayer home restored, and the pool of engine nodes.
+// The task guards are checked with guardQuerier, which may be nil.
+func initSpecyNetwork(
+	ctx context.Context,
+	log *zap.Logger,
+	homePath string,
+	prometheusMetrics *processor.PrometheusMetrics,
+	guardQuerier specy.GuardQuerier,
+) (*specy.Scheduler, *specyexecutor.EnginePool, error) {
+	if err := setSpecyEventSchema(); err != nil {
+		return nil, nil, err
+	}
+
+	store, err := specy.OpenTaskStore(homePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		if err := store.Close(); err != nil {
+			log.Warn("Failed to close specy task store", zap.Error(err))
+		}
+	}()
+
+	var metrics *specy.Metrics
+	if prometheusMetrics != nil {
+		metrics = specy.NewMetrics(prometheusMetrics.Registry)
+	}
+
+	enginePool, err := specyexecutor.ConnectSpecyEngine(ctx, log.With(zap.String("sys", "specyengine")), metrics)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log = log.With(zap.String("sys", "specy"))
+	scheduler := specy.NewScheduler(ctx, log, store, specyexecutor.ExecuteTask)
+	scheduler.SetMetrics(metrics)
+	if guardQuerier != nil {
+		scheduler.SetGuardQuerier(guardQuerier)
+	}
+	scheduler.SetWorkerPool(specy.NewWorkerPool(
+		ctx,
+		log,
+		specyconfig.Config.ExecutorWorkers,
+		specyconfig.Config.ExecutorQueueSize,
+		metrics,
+	))
+	ownership, err := specyOwnership(ctx, log, scheduler)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ownership != nil {
+		scheduler.SetOwnership(ownership)
+	}
+	if err := scheduler.SetMisfirePolicy(specyconfig.Config.MisfirePolicy, specyconfig.Config.MaxMissedRuns); err != nil {
+		return nil, nil, err
+	}
+	if err := scheduler.SetClockMode(specyconfig.Config.ClockMode); err != nil {
+		return nil, nil, err
+	}
+	scheduler.SetHistoryRetention(
+		specyconfig.Config.HistoryMaxRecords,
+		time.Duration(specyconfig.Config.HistoryRetentionHours)*time.Hour,
+	)
+	scheduler.SetExecutionLedgerRetention(time.Duration(specyconfig.Config.ExecutionLedgerRetentionHours) * time.Hour)
+	go scheduler.RunHistoryPruning(ctx, specy.DefaultHistoryPruneInterval)
+	if err := scheduler.Load(); err != nil {
+		return nil, nil, err
+	}
+	return scheduler, enginePool, nil
+}
+
+// specyOwnership returns the selection of the tasks executed by this replica among its peers,
+// nil when it runs alone.
+func specyOwnership(ctx context.Context, log *zap.Logger, scheduler *specy.Scheduler) (specy.Ownership, error) {
+	cfg := specyconfig.Config
+	sharded := cfg.ShardDir != "" || len(cfg.ShardMembers) > 0
+	if cfg.LeaseFile != "" && sharded {
+		return nil, fmt.Errorf("specy lease_file and shard membership cannot be configured together")
+	}
+	if cfg.LeaseFile == "" && !sharded {
+		return nil, nil
+	}
+
+	replicaID := cfg.ReplicaId
+	if replicaID == "" {
+		if cfg.ShardDir == "" && sharded {
+			return nil, fmt.Errorf("specy replica_id must be one of shard_members")
+		}
+		hostname, _ := os.Hostname()
+		replicaID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	log = log.With(zap.String("replica", replicaID))
+
+	if cfg.LeaseFile != "" {
+		elector := specy.NewLeaseElector(log, cfg.LeaseFile, replicaID, time.Duration(cfg.LeaseTTLSeconds)*time.Second)
+		// the lease is competed for before the tasks are loaded, so that the leader catches up their missed activations
+		if err := elector.Heartbeat(ctx); err != nil {
+			log.Warn("Failed to acquire scheduler lease", zap.String("lease", cfg.LeaseFile), zap.Error(err))
+		}
+		go elector.Run(ctx)
+		return elector, nil
+	}
+
+	if cfg.ShardDir == "" {
+		found := false
+		for _, member := range cfg.ShardMembers {
+			found = found || member == replicaID
+		}
+		if !found {
+			return nil, fmt.Errorf("specy replica_id %s is not one of shard_members", replicaID)
+		}
+	}
+	ring := specy.NewShardRing(replicaID, cfg.ShardVirtualNodes, cfg.ShardMembers)
+	if cfg.ShardDir != "" {
+		membership := specy.NewShardMembership(log, cfg.ShardDir, ring, time.Duration(cfg.ShardTTLSeconds)*time.Second)
+		// the live members are discovered before the tasks are loaded, so that each one only catches up its own tasks
+		if err := membership.Heartbeat(); err != nil {
+			log.Warn("Failed to renew shard membership", zap.String("dir", cfg.ShardDir), zap.Error(err))
+		} else if _, err := membership.Refresh(); err != nil {
+			log.Warn("Failed to list shard members", zap.String("dir", cfg.ShardDir), zap.Error(err))
+		}
+		go membership.Run(ctx, scheduler.Rebalance)
+	}
+	return ring, nil
+}
+
+// setSpecyEventSchema sets the schema the specy task events are parsed with from the specy config.
+func setSpecyEventSchema() error {
+	eventSchema, err := processor.NewSpecyEventSchema(
+		specyconfig.Config.EventSchema,
+		specyconfig.Config.EventTypes,
+		specyconfig.Config.EventAttributes,
+	)
+	if err != nil {
+		return err
+	}
+	processor.SetSpecyEventSchema(eventSchema)
+	return nil
+}
+
+// specyTargetProvider returns the provider of the specy target chain among the chains.
+func specyTargetProvider(chains map[string]*relayer.Chain, targetChainID string) (*cosmos.CosmosProvider, error) {
+	chain, ok := chains[targetChainID]
+	if !ok {
+		return nil, fmt.Errorf("specy target chain %s is not relayed by any of the started paths", targetChainID)
+	}
+	cp, ok := chain.ChainProvider.(*cosmos.CosmosProvider)
+	if !ok {
+		return nil, fmt.Errorf("specy target chain provider type %T is not supported", chain.ChainProvider)
+	}
+	return cp, nil
 }
@@ -15,7 +15,8 @@ import (
 // accepting connections on the given listener.
 // Any HTTP logging will be written at info level to the given logger.
 // The server will be forcefully shut down when ctx finishes.
-func StartDebugServer(ctx context.Context, log *zap.Logger, ln net.Listener, registry *prometheus.Registry) {
+// handlers are served in addition to the debug endpoints, by mux pattern.
+func StartDebugServer(ctx context.Context, log *zap.Logger, ln net.Listener, registry *prometheus.Registry, handlers map[string]http.Handler) {
 	// Although we could just import net/http/pprof and rely on the default global server,
 	// we may want many instances of this in test,
 	// and we will probably want more endpoints as time goes on,
@@ -39,6 +40,10 @@ func StartDebugServer(ctx context.Context, log *zap.Logger, ln net.Listener, reg
 	// Serve relayer metrics
 	mux.Handle("/relayer/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
 
+	for pattern, handler := range handlers {
+		mux.Handle(pattern, handler)
+	}
+
 	srv := &http.Server{
 		Handler:  mux,
 		ErrorLog: zap.NewStdLog(log),
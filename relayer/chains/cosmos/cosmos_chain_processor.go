@@ -57,9 +57,12 @@ type CosmosChainProcessor struct {
 
 	// parsed gas prices accepted by the chain (only used for metrics)
 	parsedGasPrices *sdk.DecCoins
+
+	// specy task scheduler fed with the blocks of the specy target chain, nil to disable
+	specyScheduler *specy.Scheduler
 }
 
-func NewCosmosChainProcessor(log *zap.Logger, provider *CosmosProvider, metrics *processor.PrometheusMetrics) *CosmosChainProcessor {
+func NewCosmosChainProcessor(log *zap.Logger, provider *CosmosProvider, metrics *processor.PrometheusMetrics, specyScheduler *specy.Scheduler) *CosmosChainProcessor {
 	return &CosmosChainProcessor{
 		log:                  log.With(zap.String("chain_name", provider.ChainName()), zap.String("chain_id", provider.ChainId())),
 		chainProvider:        provider,
@@ -69,6 +72,7 @@ func NewCosmosChainProcessor(log *zap.Logger, provider *CosmosProvider, metrics
 		connectionClients:    make(map[string]string),
 		channelConnections:   make(map[string]string),
 		metrics:              metrics,
+		specyScheduler:       specyScheduler,
 	}
 }
 
@@ -414,8 +418,7 @@ func (ccp *CosmosChainProcessor) queryCycle(ctx context.Context, persistence *qu
 		ibcHeaderCache[heightUint64] = latestHeader
 		ppChanged = true
 
-		chainId := ccp.chainProvider.ChainId()
-		//if isTargetNetwork(chainId) {
+		//if isTargetNetwork(chainID) {
 		//	// handle txs
 		//	for txIndex, tx := range blockRes.TxsResults {
 		//		// specy
@@ -425,7 +428,9 @@ func (ccp *CosmosChainProcessor) queryCycle(ctx context.Context, persistence *qu
 		//}
 
 		// deal with each block
-		specy.TriggerEveryBlockTasks()
+		if ccp.isSpecyTarget() {
+			ccp.specyScheduler.TriggerBlockTasks(i, ccp.latestBlock.Time)
+		}
 
 		// collect events and deal
 		var events []abci.Event
@@ -449,14 +454,18 @@ func (ccp *CosmosChainProcessor) queryCycle(ctx context.Context, persistence *qu
 			return nil
 		})
 		eg.Go(func() error {
-			if isTargetNetwork(chainId) {
+			if ccp.isSpecyTarget() {
 				// specy
-				processor.HandleEventWithSpecy(events, base64Encoded)
+				processor.HandleEventWithSpecy(ccp.log, ccp.metrics, ccp.specyScheduler, i, latestHeader.SignedHeader.Time, events, base64Encoded)
 			}
 			return nil
 		})
 		eg.Wait()
 
+		if ccp.isSpecyTarget() {
+			ccp.specyScheduler.SetLastProcessedHeight(i)
+		}
+
 		newLatestQueriedBlock = i
 	}
 
@@ -553,3 +562,8 @@ func (ccp *CosmosChainProcessor) CurrentRelayerBalance(ctx context.Context) {
 func isTargetNetwork(chainId string) bool {
 	return chainId == specyconfig.Config.TargetChainId
 }
+
+// isSpecyTarget reports whether this chain feeds the specy task scheduler.
+func (ccp *CosmosChainProcessor) isSpecyTarget() bool {
+	return ccp.specyScheduler != nil && isTargetNetwork(ccp.chainProvider.ChainId())
+}
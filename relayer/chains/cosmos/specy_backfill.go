@@ -0,0 +1,151 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/cosmos/relayer/v2/specy"
+	"go.uber.org/zap"
+)
+
+// BackfillSpecyTasks rebuilds the scheduler task set by replaying the specy task events
+// emitted from fromHeight up to the latest height of the chain.
+// Tasks still live at the end of the replay are registered on the scheduler,
+// cancelled tasks are unregistered, and the tasks already registered before the replayed range
+// are paused, resumed and updated as the replayed events did. The last replayed height is returned.
+func (cc *CosmosProvider) BackfillSpecyTasks(ctx context.Context, log *zap.Logger, scheduler *specy.Scheduler, fromHeight int64) (int64, error) {
+	taskSet, latestHeight, err := cc.ReplaySpecyTasks(ctx, log, fromHeight)
+	if err != nil {
+		return latestHeight, err
+	}
+
+	for taskHash, err := range taskSet.Rejected() {
+		log.Error("Skipping replayed specy task with an invalid rule file", zap.String("task_hash", taskHash), zap.Error(err))
+	}
+	for _, taskHash := range taskSet.Cancelled() {
+		scheduler.Unregister(taskHash)
+	}
+	tasks := taskSet.Tasks()
+	for _, task := range tasks {
+		if err := scheduler.Register(task); err != nil {
+			log.Error("Failed to register replayed specy task", zap.String("task_hash", task.TaskHash), zap.Error(err))
+		}
+	}
+	taskSet.ChangeRegisteredTasks(log, scheduler)
+	scheduler.SetLastProcessedHeight(latestHeight)
+
+	log.Info(
+		"Finished replaying specy task events",
+		zap.Int("live_tasks", len(tasks)),
+		zap.Int("cancelled_tasks", len(taskSet.Cancelled())),
+		zap.Int("changed_tasks", len(taskSet.Changed())),
+		zap.Int64("latest_height", latestHeight),
+	)
+
+	return latestHeight, nil
+}
+
+// ReplaySpecyTasks replays the specy task events emitted from fromHeight up to the latest height
+// of the chain, and returns the resulting task set with the last replayed height.
+// The replay starts at the earliest height available on the node when fromHeight was pruned.
+func (cc *CosmosProvider) ReplaySpecyTasks(ctx context.Context, log *zap.Logger, fromHeight int64) (*processor.SpecyTaskSet, int64, error) {
+	if fromHeight < 1 {
+		fromHeight = 1
+	}
+
+	status, err := cc.QueryStatus(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	cc.setCometVersion(log, status.NodeInfo.Version)
+	latestHeight := status.SyncInfo.LatestBlockHeight
+
+	// the blocks pruned by the node cannot be replayed
+	if earliest := status.SyncInfo.EarliestBlockHeight; fromHeight < earliest {
+		log.Warn(
+			"Specy task events below the earliest height available on the node cannot be replayed",
+			zap.Int64("from_height", fromHeight),
+			zap.Int64("earliest_height", earliest),
+		)
+		fromHeight = earliest
+	}
+
+	log.Info(
+		"Replaying specy task events",
+		zap.Int64("from_height", fromHeight),
+		zap.Int64("latest_height", latestHeight),
+	)
+
+	taskSet := processor.NewSpecyTaskSet(log)
+	for height := fromHeight; height <= latestHeight; height++ {
+		blockRes, err := cc.blockResultsWithRetry(ctx, log, height)
+		if err != nil {
+			return nil, height - 1, fmt.Errorf("failed to query block results at height %d: %w", height, err)
+		}
+
+		// events are applied in the order they were emitted within the block
+		var events []abci.Event
+		events = append(events, blockRes.BeginBlockEvents...)
+		for _, tx := range blockRes.TxsResults {
+			if tx.Code != 0 {
+				// tx was not successful
+				continue
+			}
+			events = append(events, tx.Events...)
+		}
+		events = append(events, blockRes.EndBlockEvents...)
+
+		// the tasks are anchored on the time of their block, only queried for the blocks with task events
+		var blockTime time.Time
+		if processor.ContainsSpecyTaskEvents(events, cc.cometLegacyEncoding) {
+			if blockTime, err = cc.blockTimeWithRetry(ctx, log, height); err != nil {
+				return nil, height - 1, fmt.Errorf("failed to query block time at height %d: %w", height, err)
+			}
+		}
+		taskSet.Apply(blockTime, events, cc.cometLegacyEncoding)
+	}
+	return taskSet, latestHeight, nil
+}
+
+// blockTimeWithRetry will query for the time of the block at the given height, retrying in case of failure.
+func (cc *CosmosProvider) blockTimeWithRetry(ctx context.Context, log *zap.Logger, height int64) (blockTime time.Time, err error) {
+	return blockTime, retry.Do(func() error {
+		queryCtx, cancelQueryCtx := context.WithTimeout(ctx, blockResultsQueryTimeout)
+		defer cancelQueryCtx()
+		var err error
+		blockTime, err = cc.BlockTime(queryCtx, height)
+		return err
+	}, retry.Context(ctx), retry.Attempts(latestHeightQueryRetries), retry.Delay(latestHeightQueryRetryDelay), retry.LastErrorOnly(true), retry.OnRetry(func(n uint, err error) {
+		log.Error(
+			"Failed to query block time",
+			zap.Int64("height", height),
+			zap.Uint("attempt", n+1),
+			zap.Uint("max_attempts", latestHeightQueryRetries),
+			zap.Error(err),
+		)
+	}))
+}
+
+// blockResultsWithRetry will query for the block results at the given height, retrying in case of failure.
+func (cc *CosmosProvider) blockResultsWithRetry(ctx context.Context, log *zap.Logger, height int64) (blockRes *ctypes.ResultBlockResults, err error) {
+	return blockRes, retry.Do(func() error {
+		queryCtx, cancelQueryCtx := context.WithTimeout(ctx, blockResultsQueryTimeout)
+		defer cancelQueryCtx()
+		var err error
+		blockRes, err = cc.RPCClient.BlockResults(queryCtx, &height)
+		return err
+	}, retry.Context(ctx), retry.Attempts(latestHeightQueryRetries), retry.Delay(latestHeightQueryRetryDelay), retry.LastErrorOnly(true), retry.OnRetry(func(n uint, err error) {
+		log.Error(
+			"Failed to query block results",
+			zap.Int64("height", height),
+			zap.Uint("attempt", n+1),
+			zap.Uint("max_attempts", latestHeightQueryRetries),
+			zap.Error(err),
+		)
+	}))
+}
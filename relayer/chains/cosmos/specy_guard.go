@@ -0,0 +1,80 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/relayer/v2/specy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const wasmSmartContractStatePath = "/cosmwasm.wasm.v1.Query/SmartContractState"
+
+var _ specy.GuardQuerier = (*SpecyGuardQuerier)(nil)
+
+// SpecyGuardQuerier answers the guard queries of specy tasks from the chain.
+type SpecyGuardQuerier struct {
+	cc *CosmosProvider
+}
+
+// NewSpecyGuardQuerier returns a specy.GuardQuerier querying the chain of the provider.
+func NewSpecyGuardQuerier(cc *CosmosProvider) *SpecyGuardQuerier {
+	return &SpecyGuardQuerier{cc: cc}
+}
+
+// Balance returns the amount of denom held by address.
+func (q *SpecyGuardQuerier) Balance(ctx context.Context, address, denom string) (string, error) {
+	coins, err := q.cc.QueryBalanceWithAddress(ctx, address)
+	if err != nil {
+		return "", err
+	}
+	return coins.AmountOf(denom).String(), nil
+}
+
+// WasmSmartQuery runs a smart query against a wasm contract and returns its JSON result.
+// The request and response are encoded by hand to avoid depending on the wasmd module.
+func (q *SpecyGuardQuerier) WasmSmartQuery(ctx context.Context, contract string, query []byte) ([]byte, error) {
+	// QuerySmartContractStateRequest{address = 1, query_data = 2}
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	req = protowire.AppendString(req, contract)
+	req = protowire.AppendTag(req, 2, protowire.BytesType)
+	req = protowire.AppendBytes(req, query)
+
+	res, err := q.cc.QueryABCI(ctx, abci.RequestQuery{
+		Path: wasmSmartContractStatePath,
+		Data: req,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// QuerySmartContractStateResponse{data = 1}
+	bz := res.Value
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid smart query response: %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+		if num == 1 && typ == protowire.BytesType {
+			data, n := protowire.ConsumeBytes(bz)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid smart query response: %w", protowire.ParseError(n))
+			}
+			return data, nil
+		}
+		n = protowire.ConsumeFieldValue(num, typ, bz)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid smart query response: %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+	}
+	return nil, fmt.Errorf("empty smart query response")
+}
+
+// LatestHeight returns the latest height of the chain.
+func (q *SpecyGuardQuerier) LatestHeight(ctx context.Context) (int64, error) {
+	return q.cc.QueryLatestHeight(ctx)
+}
@@ -12,6 +12,7 @@ type PrometheusMetrics struct {
 	LatestHeightGauge     *prometheus.GaugeVec
 	WalletBalance         *prometheus.GaugeVec
 	FeesSpent             *prometheus.GaugeVec
+	SpecyRuleFileErrors   *prometheus.CounterVec
 }
 
 func (m *PrometheusMetrics) AddPacketsObserved(path, chain, channel, port, eventType string, count int) {
@@ -34,10 +35,15 @@ func (m *PrometheusMetrics) SetFeesSpent(chain, key, denom string, amount float6
 	m.FeesSpent.WithLabelValues(chain, key, denom).Set(amount)
 }
 
+func (m *PrometheusMetrics) IncSpecyRuleFileErrors(clause string) {
+	m.SpecyRuleFileErrors.WithLabelValues(clause).Inc()
+}
+
 func NewPrometheusMetrics() *PrometheusMetrics {
 	packetLabels := []string{"path", "chain", "channel", "port", "type"}
 	heightLabels := []string{"chain"}
 	walletLabels := []string{"chain", "key", "denom"}
+	ruleFileLabels := []string{"clause"}
 	registry := prometheus.NewRegistry()
 	registerer := promauto.With(registry)
 	return &PrometheusMetrics{
@@ -62,5 +68,9 @@ func NewPrometheusMetrics() *PrometheusMetrics {
 			Name: "cosmos_relayer_fees_spent",
 			Help: "The amount of fees spent from the relayer's wallet",
 		}, walletLabels),
+		SpecyRuleFileErrors: registerer.NewCounterVec(prometheus.CounterOpts{
+			Name: "specy_rule_file_parse_errors",
+			Help: "The total number of specy task rule files rejected, by failing clause",
+		}, ruleFileLabels),
 	}
 }
@@ -1,22 +1,35 @@
 package processor
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	abci "github.com/cometbft/cometbft/abci/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/relayer/v2/specy"
 	"github.com/cosmos/relayer/v2/utils"
-	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"go.uber.org/zap"
 )
 
+// HandleEventWithSpecy applies the specy task events of a block to the scheduler,
+// and triggers the on_event tasks matching the events of the block. metrics may be nil.
 func HandleEventWithSpecy(
+	log *zap.Logger,
+	metrics *PrometheusMetrics,
+	scheduler *specy.Scheduler,
+	height int64,
+	blockTime time.Time,
 	events []abci.Event,
 	base64Encoded bool,
 ) {
 
-	for _, event := range events {
+	schema := currentSpecyEventSchema()
+	for i, event := range events {
 		var evt sdk.StringEvent
 		if base64Encoded {
 			evt = utils.ParseBase64Event(event)
@@ -24,114 +37,404 @@ func HandleEventWithSpecy(
 			evt = sdk.StringifyEvent(event)
 		}
 
-		switch evt.Type {
-		case "create_task":
-			registerTaskOnScheduler(evt)
+		kind, fields, err := schema.parse(evt)
+		if err != nil {
+			log.Error("Failed to parse specy task event", zap.String("event_type", evt.Type), zap.Error(err))
+			kind = ""
+		} else {
+			fields.logUnknown(log)
+		}
+		taskHash, _ := fields.get(SpecyAttrTaskHash)
+
+		switch kind {
+		case SpecyEventCreateTask:
+			registerTaskOnScheduler(log, metrics, scheduler, blockTime, fields)
+
+		case SpecyEventCancelTask:
+			// 取消注册任务
+			scheduler.Unregister(taskHash)
+
+		case SpecyEventPauseTask:
+			if err := scheduler.Pause(taskHash); err != nil {
+				log.Error("Failed to pause specy task", zap.Error(err))
+			}
+
+		case SpecyEventResumeTask:
+			if err := scheduler.Resume(taskHash); err != nil {
+				log.Error("Failed to resume specy task", zap.Error(err))
+			}
 
-		case "cancle_task":
-			unregisterTaskOnScheduler(evt)
+		case SpecyEventUpdateTask:
+			updateTaskOnScheduler(log, metrics, scheduler, blockTime, fields)
 		}
 
+		scheduler.TriggerEventTasks(height, blockTime, specyEvent(evt, i))
+
 		// listen regulatory events and init or update info
 		//case "register":
 		//case "relation":
 	}
 }
 
-func registerTaskOnScheduler(evt sdk.StringEvent) {
-	// 入参打印
-	fmt.Printf("event: %+v \n", evt)
-
-	var creator string
-	var taskName string
-	var taskHash string
-	var connectionId string
-	var msgs string
-	var ruleFile string
-	var taskType string
-	var intervalType string
-	var interval int
-	var startTime time.Time
-	for _, attr := range evt.Attributes {
-		switch attr.Key {
-		case "creator":
-			creator = attr.Value
-		case "task_name":
-			taskName = attr.Value
-		case "task_hash":
-			taskHash = attr.Value
-		case "connect_id":
-			connectionId = attr.Value
-		case "task_msgs":
-			msgs = attr.Value
-		case "task_rule_file":
-			ruleFile = attr.Value
-			reg := regexp.MustCompile(`after (\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\+\d{2}:\d{2})`)
-			match := reg.FindStringSubmatch(ruleFile)
-
-			if len(match) > 1 {
-				// ruleFile 中指定了执行时间的情况
-				dateTimeStr := match[1]
-				// 解析
-				startTime = parseAndCalcStartTime(dateTimeStr)
-				// 执行间隔默认是24小时
-				if interval == 0 {
-					interval = 24 * 60 * 60
+// SpecyTaskSet accumulates the effect of the specy task events
+// without scheduling anything, so that historical events can be replayed in order.
+type SpecyTaskSet struct {
+	log *zap.Logger
+
+	order     []string
+	tasks     map[string]*specy.Task
+	cancelled map[string]bool
+	rejected  map[string]error
+
+	// changes holds the pauses, resumes and updates of the tasks created before the replayed range,
+	// in the order they were emitted, by task hash.
+	changes map[string][]specyTaskChange
+}
+
+// specyTaskChange is a pause_task, resume_task or update_task event of a task created before the replayed range.
+type specyTaskChange struct {
+	kind      string
+	blockTime time.Time
+	fields    specyEventFields
+}
+
+// NewSpecyTaskSet returns an empty SpecyTaskSet, warning through log about the ignored attributes of the events.
+func NewSpecyTaskSet(log *zap.Logger) *SpecyTaskSet {
+	return &SpecyTaskSet{
+		log:       log,
+		tasks:     make(map[string]*specy.Task),
+		cancelled: make(map[string]bool),
+		rejected:  make(map[string]error),
+		changes:   make(map[string][]specyTaskChange),
+	}
+}
+
+// Apply applies the specy task events of a single block, in the order they were emitted.
+// The tasks created or updated by the events are anchored on blockTime, the time of the block.
+func (s *SpecyTaskSet) Apply(blockTime time.Time, events []abci.Event, base64Encoded bool) {
+	schema := currentSpecyEventSchema()
+	for _, event := range events {
+		var evt sdk.StringEvent
+		if base64Encoded {
+			evt = utils.ParseBase64Event(event)
+		} else {
+			evt = sdk.StringifyEvent(event)
+		}
+
+		kind, fields, err := schema.parse(evt)
+		taskHash, _ := fields.get(SpecyAttrTaskHash)
+		if err != nil {
+			s.rejected[taskHash] = err
+			continue
+		}
+		fields.logUnknown(s.log)
+
+		switch kind {
+		case SpecyEventCreateTask:
+			task, err := parseTaskEvent(blockTime, fields)
+			if err != nil {
+				s.rejected[taskHash] = err
+				continue
+			}
+			delete(s.rejected, task.TaskHash)
+			if _, ok := s.tasks[task.TaskHash]; !ok {
+				s.order = append(s.order, task.TaskHash)
+			}
+			s.tasks[task.TaskHash] = task
+			delete(s.cancelled, task.TaskHash)
+			delete(s.changes, task.TaskHash)
+
+		case SpecyEventCancelTask:
+			if _, ok := s.tasks[taskHash]; ok {
+				delete(s.tasks, taskHash)
+				for i, hash := range s.order {
+					if hash == taskHash {
+						s.order = append(s.order[:i], s.order[i+1:]...)
+						break
+					}
 				}
+			}
+			s.cancelled[taskHash] = true
+			delete(s.changes, taskHash)
+
+		case SpecyEventPauseTask, SpecyEventResumeTask:
+			if task, ok := s.tasks[taskHash]; ok {
+				t := *task
+				t.Paused = kind == SpecyEventPauseTask
+				s.tasks[taskHash] = &t
 			} else {
-				// ruleFile 中没有指定执行时间 默认是现在开始(延迟30s)
-				startTime = time.Now().Add(30 * time.Second)
-			}
-		case "task_type":
-			taskType = attr.Value
-		case "task_interval_type":
-			intervalType = attr.Value
-		case "task_interval_number":
-			interval, _ = strconv.Atoi(attr.Value)
-		default:
-			continue
+				s.changes[taskHash] = append(s.changes[taskHash], specyTaskChange{kind: kind, blockTime: blockTime, fields: fields})
+			}
+
+		case SpecyEventUpdateTask:
+			// the updates of the tasks created before the replayed range are checked against an empty task
+			task, ok := s.tasks[taskHash]
+			if !ok {
+				task = &specy.Task{TaskHash: taskHash}
+			}
+			updated, err := mergeTaskEvent(task, blockTime, fields)
+			if err != nil {
+				s.rejected[taskHash] = err
+				continue
+			}
+			if ok {
+				s.tasks[taskHash] = updated
+			} else {
+				s.changes[taskHash] = append(s.changes[taskHash], specyTaskChange{kind: kind, blockTime: blockTime, fields: fields})
+			}
 		}
 	}
+}
 
-	// 注册任务
-	task := specy.NewTask(taskHash, taskName, creator, connectionId, msgs, ruleFile, taskType, intervalType, interval, startTime)
-	specy.RegisterTask(task)
+// ContainsSpecyTaskEvents reports whether the events of a block include specy task events,
+// which SpecyTaskSet.Apply needs the time of the block for.
+func ContainsSpecyTaskEvents(events []abci.Event, base64Encoded bool) bool {
+	schema := currentSpecyEventSchema()
+	for _, event := range events {
+		var evt sdk.StringEvent
+		if base64Encoded {
+			evt = utils.ParseBase64Event(event)
+		} else {
+			evt = sdk.StringifyEvent(event)
+		}
+		if kind, _, err := schema.parse(evt); kind != "" || err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Tasks returns the tasks that are still live, in the order they were created.
+func (s *SpecyTaskSet) Tasks() []*specy.Task {
+	tasks := make([]*specy.Task, len(s.order))
+	for i, hash := range s.order {
+		tasks[i] = s.tasks[hash]
+	}
+	return tasks
+}
+
+// Rejected returns the errors of the task events that could not be parsed, by task hash.
+func (s *SpecyTaskSet) Rejected() map[string]error {
+	return s.rejected
+}
+
+// Cancelled returns the hashes of the tasks that were cancelled and not created again afterwards.
+func (s *SpecyTaskSet) Cancelled() []string {
+	hashes := make([]string, 0, len(s.cancelled))
+	for hash := range s.cancelled {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// Changed returns the hashes of the tasks created before the replayed range that were paused, resumed or updated.
+func (s *SpecyTaskSet) Changed() []string {
+	hashes := make([]string, 0, len(s.changes))
+	for hash := range s.changes {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// ChangeRegisteredTasks applies to the tasks registered on the scheduler, such as the ones loaded from its store,
+// the pauses, resumes and updates replayed for the tasks created before the replayed range.
+func (s *SpecyTaskSet) ChangeRegisteredTasks(log *zap.Logger, scheduler *specy.Scheduler) {
+	for _, taskHash := range s.Changed() {
+		for _, change := range s.changes[taskHash] {
+			var err error
+			switch change.kind {
+			case SpecyEventPauseTask:
+				err = scheduler.Pause(taskHash)
+			case SpecyEventResumeTask:
+				err = scheduler.Resume(taskHash)
+			case SpecyEventUpdateTask:
+				updateTaskOnScheduler(log, nil, scheduler, change.blockTime, change.fields)
+			}
+			if err != nil {
+				log.Error("Failed to apply replayed specy task event", zap.String("task_hash", taskHash), zap.String("event", change.kind), zap.Error(err))
+			}
+		}
+	}
 }
 
-func parseAndCalcStartTime(dateTimeStr string) time.Time {
-	fmt.Println(dateTimeStr)
+func registerTaskOnScheduler(log *zap.Logger, metrics *PrometheusMetrics, scheduler *specy.Scheduler, blockTime time.Time, fields specyEventFields) {
+	task, err := parseTaskEvent(blockTime, fields)
+	if err != nil {
+		logTaskParseError(log, metrics, fields, err)
+		return
+	}
 
-	// 将字符串转换为 time.Time 类型
-	dateTime, err := time.Parse("2006-01-02T15:04:05-07:00", dateTimeStr)
+	// 注册任务
+	if err := scheduler.Register(task); err != nil {
+		log.Error("Failed to register specy task", zap.Error(err))
+	}
+}
+
+func updateTaskOnScheduler(log *zap.Logger, metrics *PrometheusMetrics, scheduler *specy.Scheduler, blockTime time.Time, fields specyEventFields) {
+	taskHash, _ := fields.get(SpecyAttrTaskHash)
+	task, ok := scheduler.Get(taskHash)
+	if !ok {
+		log.Error("Failed to update specy task", zap.String("task_hash", taskHash), zap.Error(fmt.Errorf("unknown task %s", taskHash)))
+		return
+	}
+	update, err := mergeTaskEvent(task, blockTime, fields)
 	if err != nil {
-		fmt.Println("日期时间解析失败:", err)
-	} else {
-		fmt.Println(dateTime)
+		logTaskParseError(log, metrics, fields, err)
+		return
 	}
 
-	now := time.Now()
-	var startTime time.Time
-	if dateTime.Before(now) || dateTime.Equal(now) {
-		startTime = time.Date(now.Year(), now.Month(), now.Day(), dateTime.Hour(), dateTime.Minute(), dateTime.Second(), 0, now.Location())
-	} else {
-		startTime = dateTime
+	if err := scheduler.Update(update); err != nil {
+		log.Error("Failed to update specy task", zap.Error(err))
 	}
-	if startTime.Before(now) || startTime.Equal(now) {
-		startTime = startTime.AddDate(0, 0, 1)
+}
+
+func logTaskParseError(log *zap.Logger, metrics *PrometheusMetrics, fields specyEventFields, err error) {
+	taskHash, _ := fields.get(SpecyAttrTaskHash)
+	log.Error("Failed to parse specy task event", zap.String("task_hash", taskHash), zap.Error(err))
+	var parseErr *specy.ParseError
+	if metrics != nil && errors.As(err, &parseErr) {
+		metrics.IncSpecyRuleFileErrors(parseErr.Clause)
 	}
+}
+
+// specyScheduleAttributes are the attributes of a task event describing the schedule of the task.
+// An update_task event carrying any of them replaces the schedule of the task as a whole.
+var specyScheduleAttributes = []string{
+	SpecyAttrRuleFile, SpecyAttrIntervalType, SpecyAttrIntervalNumber, SpecyAttrCronExpression, SpecyAttrDependsOn,
+}
 
-	return startTime
+// parseTaskEvent builds a task from the attributes of a create_task event emitted in the block of blockTime.
+// It fails if an attribute is malformed or if the scheduling clauses of the rule file cannot be parsed.
+func parseTaskEvent(blockTime time.Time, fields specyEventFields) (*specy.Task, error) {
+	return mergeTaskEvent(nil, blockTime, fields)
 }
 
-func unregisterTaskOnScheduler(evt sdk.StringEvent) {
-	var taskHash string
-	for _, attr := range evt.Attributes {
-		switch attr.Key {
-		case "task_hash":
-			taskHash = attr.Value
+// mergeTaskEvent returns a copy of the task changed by the attributes of an update_task event emitted
+// in the block of blockTime, or the task created by a create_task event when task is nil.
+// The attributes missing from an update leave the task unchanged, except for the scheduling attributes:
+// when the update carries one of them, the schedule of the task is rebuilt from the scheduling attributes
+// of the update alone, which must then include the interval type unless it sets depends_on.
+// The creator, name, connection and type of a task are set on creation only.
+func mergeTaskEvent(task *specy.Task, blockTime time.Time, fields specyEventFields) (*specy.Task, error) {
+	var t specy.Task
+	rescheduled := task == nil
+	if task != nil {
+		t = *task
+		for _, name := range specyScheduleAttributes {
+			_, ok := fields.get(name)
+			rescheduled = rescheduled || ok
+		}
+		_, hasIntervalType := fields.get(SpecyAttrIntervalType)
+		_, hasDependsOn := fields.get(SpecyAttrDependsOn)
+		if rescheduled && !hasIntervalType && !hasDependsOn {
+			return nil, fields.errorf(SpecyAttrIntervalType, "required to change the schedule of the task")
+		}
+	}
+	if rescheduled {
+		// ruleFile 中没有指定执行时间 默认是现在开始(延迟30s)
+		// anchored on the time of the block, so that every executor schedules the task alike
+		t.RuleFile = ""
+		t.DependsOn = nil
+		t.Condition.IntervalType = ""
+		t.Condition.Interval = 0
+		t.Condition.CronExpression = ""
+		t.Condition.StartTime = blockTime.Add(30 * time.Second)
+		t.Condition.EndTime = time.Time{}
+	}
+
+	var err error
+	for name, value := range fields.values {
+		switch name {
+		case SpecyAttrCreator:
+			if task == nil {
+				t.Creator = value
+			}
+		case SpecyAttrTaskName:
+			if task == nil {
+				t.TaskName = value
+			}
+		case SpecyAttrTaskHash:
+			t.TaskHash = value
+		case SpecyAttrConnectionID:
+			if task == nil {
+				t.ConnectionId = value
+			}
+		case SpecyAttrMsgs:
+			t.Msgs = value
+		case SpecyAttrRuleFile:
+			t.RuleFile = value
+		case SpecyAttrTaskType:
+			if task == nil {
+				t.TaskType = value
+			}
+		case SpecyAttrIntervalType:
+			t.Condition.IntervalType = value
+		case SpecyAttrIntervalNumber:
+			t.Condition.Interval, err = strconv.Atoi(value)
+		case SpecyAttrCronExpression:
+			t.Condition.CronExpression = value
+		case SpecyAttrStartHeight:
+			t.Condition.StartHeight, err = strconv.ParseInt(value, 10, 64)
+		case SpecyAttrEndHeight:
+			t.Condition.EndHeight, err = strconv.ParseInt(value, 10, 64)
+		case SpecyAttrSkipIfRunning:
+			t.SkipIfRunning, err = strconv.ParseBool(value)
+		case SpecyAttrMisfirePolicy:
+			t.Condition.MisfirePolicy = value
+		case SpecyAttrMaxMissedRuns:
+			t.Condition.MaxMissedRuns, err = strconv.Atoi(value)
+		case SpecyAttrEventType:
+			t.Condition.EventType = value
+		case SpecyAttrEventPredicates:
+			var predicates []specy.EventPredicate
+			if err = json.Unmarshal([]byte(value), &predicates); err == nil {
+				t.Condition.EventPredicates = predicates
+			}
+		case SpecyAttrDependsOn:
+			var dependsOn []string
+			for _, hash := range strings.Split(value, ",") {
+				if hash = strings.TrimSpace(hash); hash != "" {
+					dependsOn = append(dependsOn, hash)
+				}
+			}
+			t.DependsOn = dependsOn
+		case SpecyAttrGuard:
+			var guard *specy.Guard
+			if err = json.Unmarshal([]byte(value), &guard); err == nil {
+				t.Guard = guard
+			}
+		}
+		if err != nil {
+			return nil, fields.errorf(name, "invalid value %q: %v", value, err)
 		}
 	}
 
-	// 取消注册任务
-	specy.UnregisterTask(taskHash)
+	if !rescheduled {
+		return &t, nil
+	}
+	schedule, err := specy.ParseRuleSchedule(t.RuleFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.DependsOn) > 0 {
+		// 依赖其他任务的任务由父任务的执行结果触发
+		t.Condition.IntervalType = specy.IntervalTypeDependent
+	}
+	schedule.ApplyTo(&t.Condition)
+	return &t, nil
+}
+
+// specyEvent converts a chain event to the event matched by on_event tasks.
+func specyEvent(evt sdk.StringEvent, index int) specy.Event {
+	event := specy.Event{
+		Type:       evt.Type,
+		Attributes: make([]specy.EventAttribute, len(evt.Attributes)),
+		Index:      index,
+	}
+	for i, attr := range evt.Attributes {
+		event.Attributes[i] = specy.EventAttribute{Key: attr.Key, Value: attr.Value}
+	}
+	return event
 }
@@ -0,0 +1,218 @@
+package processor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var specyBlockTime = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func specyTaskEvent(eventType, taskHash string) abci.Event {
+	return abci.Event{
+		Type: eventType,
+		Attributes: []abci.EventAttribute{
+			{Key: "task_hash", Value: taskHash},
+			{Key: "creator", Value: "creator"},
+			{Key: "task_name", Value: "task-" + taskHash},
+			{Key: "task_interval_type", Value: "every_block"},
+			{Key: "connect_id", Value: "connection-0"},
+		},
+	}
+}
+
+// renameSpecyAttribute renames the attribute of the event with the given key.
+func renameSpecyAttribute(event abci.Event, key, newKey string) abci.Event {
+	attributes := make([]abci.EventAttribute, len(event.Attributes))
+	copy(attributes, event.Attributes)
+	for i := range attributes {
+		if attributes[i].Key == key {
+			attributes[i].Key = newKey
+		}
+	}
+	event.Attributes = attributes
+	return event
+}
+
+func TestSpecyTaskSetReplay(t *testing.T) {
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+
+	taskSet.Apply(specyBlockTime, []abci.Event{
+		specyTaskEvent("create_task", "a"),
+		specyTaskEvent("create_task", "b"),
+		{Type: "transfer"},
+	}, false)
+	taskSet.Apply(specyBlockTime, []abci.Event{
+		specyTaskEvent("cancle_task", "a"),
+		specyTaskEvent("create_task", "c"),
+		specyTaskEvent("cancle_task", "d"),
+	}, false)
+
+	tasks := taskSet.Tasks()
+	require.Len(t, tasks, 2)
+	require.Equal(t, "b", tasks[0].TaskHash)
+	require.Equal(t, "task-b", tasks[0].TaskName)
+	require.Equal(t, "c", tasks[1].TaskHash)
+	require.Equal(t, []string{"a", "d"}, taskSet.Cancelled())
+
+	// re-creating a cancelled task makes it live again, at the end of the creation order
+	taskSet.Apply(specyBlockTime, []abci.Event{specyTaskEvent("create_task", "a")}, false)
+	tasks = taskSet.Tasks()
+	require.Len(t, tasks, 3)
+	require.Equal(t, "a", tasks[2].TaskHash)
+	require.Equal(t, []string{"d"}, taskSet.Cancelled())
+}
+
+func TestSpecyTaskSetRejectsInvalidRuleFile(t *testing.T) {
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+
+	invalid := specyTaskEvent("create_task", "a")
+	invalid.Attributes = append(invalid.Attributes, abci.EventAttribute{Key: "task_rule_file", Value: "count after 2023-13-01T00:00:00Z"})
+	valid := specyTaskEvent("create_task", "b")
+	valid.Attributes = append(valid.Attributes, abci.EventAttribute{Key: "task_rule_file", Value: "count after 2023-01-01T00:00:00Z"})
+
+	taskSet.Apply(specyBlockTime, []abci.Event{invalid, valid}, false)
+
+	tasks := taskSet.Tasks()
+	require.Len(t, tasks, 1)
+	require.Equal(t, "b", tasks[0].TaskHash)
+	require.Contains(t, taskSet.Rejected(), "a")
+}
+
+func TestSpecyTaskSetPauseResumeUpdate(t *testing.T) {
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+
+	update := specyTaskEvent("update_task", "a")
+	update.Attributes = append(update.Attributes,
+		abci.EventAttribute{Key: "task_interval_type", Value: "every_n_blocks"},
+		abci.EventAttribute{Key: "task_interval_number", Value: "5"},
+		abci.EventAttribute{Key: "task_msgs", Value: "updated"},
+	)
+	taskSet.Apply(specyBlockTime, []abci.Event{
+		specyTaskEvent("create_task", "a"),
+		specyTaskEvent("create_task", "b"),
+		specyTaskEvent("pause_task", "a"),
+		specyTaskEvent("pause_task", "b"),
+		specyTaskEvent("resume_task", "b"),
+		update,
+	}, false)
+
+	tasks := taskSet.Tasks()
+	require.Len(t, tasks, 2)
+	require.True(t, tasks[0].Paused)
+	require.Equal(t, "task-a", tasks[0].TaskName)
+	require.Equal(t, "updated", tasks[0].Msgs)
+	require.Equal(t, "every_n_blocks", tasks[0].Condition.IntervalType)
+	require.Equal(t, 5, tasks[0].Condition.Interval)
+	require.False(t, tasks[1].Paused)
+}
+
+func TestSpecyTaskSetMergesUpdates(t *testing.T) {
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+
+	create := specyTaskEvent("create_task", "a")
+	create.Attributes = append(create.Attributes,
+		abci.EventAttribute{Key: "task_msgs", Value: "msgs"},
+		abci.EventAttribute{Key: "task_rule_file", Value: "count every 2 hours"},
+		abci.EventAttribute{Key: "task_guard", Value: `{"type":"height","op":"gte","value":"10"}`},
+		abci.EventAttribute{Key: "task_skip_if_running", Value: "true"},
+	)
+	create.Attributes[3].Value = "time_interval"
+	taskSet.Apply(specyBlockTime, []abci.Event{create}, false)
+	created := taskSet.Tasks()[0]
+	require.Equal(t, 2*60*60, created.Condition.Interval)
+
+	// an update without scheduling attributes only changes the attributes it carries
+	update := abci.Event{Type: "update_task", Attributes: []abci.EventAttribute{
+		{Key: "task_hash", Value: "a"},
+		{Key: "task_msgs", Value: "updated"},
+	}}
+	taskSet.Apply(specyBlockTime.Add(time.Hour), []abci.Event{update}, false)
+	updated := taskSet.Tasks()[0]
+	require.Equal(t, "updated", updated.Msgs)
+	require.Equal(t, created.Condition, updated.Condition)
+	require.Equal(t, created.RuleFile, updated.RuleFile)
+	require.Equal(t, created.Guard, updated.Guard)
+	require.True(t, updated.SkipIfRunning)
+
+	// the schedule is replaced as a whole, with its interval type
+	update.Attributes[1] = abci.EventAttribute{Key: "task_rule_file", Value: "count every 1 hour"}
+	taskSet.Apply(specyBlockTime.Add(2*time.Hour), []abci.Event{update}, false)
+	require.ErrorContains(t, taskSet.Rejected()["a"], "update_task attribute task_interval_type: required to change the schedule of the task")
+	require.Equal(t, created.Condition, taskSet.Tasks()[0].Condition)
+
+	update.Attributes = append(update.Attributes, abci.EventAttribute{Key: "task_interval_type", Value: "time_interval"})
+	taskSet.Apply(specyBlockTime.Add(2*time.Hour), []abci.Event{update}, false)
+	updated = taskSet.Tasks()[0]
+	require.Equal(t, 60*60, updated.Condition.Interval)
+	require.Equal(t, specyBlockTime.Add(2*time.Hour+30*time.Second), updated.Condition.StartTime)
+	require.Equal(t, "updated", updated.Msgs)
+	require.Equal(t, created.Guard, updated.Guard)
+}
+
+func TestSpecyTaskSetChangesTasksCreatedBeforeTheReplay(t *testing.T) {
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil,
+		func(context.Context, *specy.Task, specy.Trigger, *specy.ExecutionRecord) error { return nil })
+
+	// the tasks were created before the height the replay resumes from, and loaded from the store
+	created := processor.NewSpecyTaskSet(zap.NewNop())
+	created.Apply(specyBlockTime, []abci.Event{specyTaskEvent("create_task", "a"), specyTaskEvent("create_task", "b")}, false)
+	for _, task := range created.Tasks() {
+		require.NoError(t, scheduler.Register(task))
+	}
+	require.NoError(t, scheduler.Pause("b"))
+
+	update := specyTaskEvent("update_task", "a")
+	update.Attributes = append(update.Attributes,
+		abci.EventAttribute{Key: "task_interval_type", Value: "every_n_blocks"},
+		abci.EventAttribute{Key: "task_interval_number", Value: "5"},
+	)
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+	taskSet.Apply(specyBlockTime.Add(time.Hour), []abci.Event{
+		specyTaskEvent("pause_task", "a"),
+		update,
+		specyTaskEvent("resume_task", "b"),
+	}, false)
+	require.Empty(t, taskSet.Tasks())
+	require.Equal(t, []string{"a", "b"}, taskSet.Changed())
+
+	taskSet.ChangeRegisteredTasks(zap.NewNop(), scheduler)
+	a, ok := scheduler.Get("a")
+	require.True(t, ok)
+	require.True(t, a.Paused)
+	require.Equal(t, "every_n_blocks", a.Condition.IntervalType)
+	require.Equal(t, 5, a.Condition.Interval)
+	b, ok := scheduler.Get("b")
+	require.True(t, ok)
+	require.False(t, b.Paused)
+
+	// the changes of the tasks cancelled or created again within the replay are dropped
+	taskSet.Apply(specyBlockTime.Add(2*time.Hour), []abci.Event{specyTaskEvent("cancle_task", "a"), specyTaskEvent("create_task", "b")}, false)
+	require.Empty(t, taskSet.Changed())
+}
+
+func TestSpecyTaskSetAnchorsOnBlockTime(t *testing.T) {
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+
+	create := specyTaskEvent("create_task", "a")
+	create.Attributes[3].Value = "time_interval"
+	create.Attributes = append(create.Attributes, abci.EventAttribute{Key: "task_interval_number", Value: "60"})
+	taskSet.Apply(specyBlockTime, []abci.Event{create}, false)
+
+	// tasks without an after clause start shortly after the block that created them
+	tasks := taskSet.Tasks()
+	require.Len(t, tasks, 1)
+	require.Equal(t, specyBlockTime.Add(30*time.Second), tasks[0].Condition.StartTime)
+
+	// and updated ones after the block that updated them
+	update := create
+	update.Type = "update_task"
+	taskSet.Apply(specyBlockTime.Add(time.Hour), []abci.Event{update}, false)
+	require.Equal(t, specyBlockTime.Add(time.Hour+30*time.Second), taskSet.Tasks()[0].Condition.StartTime)
+}
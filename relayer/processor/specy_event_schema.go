@@ -0,0 +1,265 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/zap"
+)
+
+// Canonical names of the specy task events.
+const (
+	SpecyEventCreateTask = "create_task"
+	SpecyEventCancelTask = "cancel_task"
+	SpecyEventPauseTask  = "pause_task"
+	SpecyEventResumeTask = "resume_task"
+	SpecyEventUpdateTask = "update_task"
+)
+
+// Canonical names of the attributes of the specy task events.
+const (
+	SpecyAttrCreator         = "creator"
+	SpecyAttrTaskName        = "task_name"
+	SpecyAttrTaskHash        = "task_hash"
+	SpecyAttrConnectionID    = "connection_id"
+	SpecyAttrMsgs            = "task_msgs"
+	SpecyAttrRuleFile        = "task_rule_file"
+	SpecyAttrTaskType        = "task_type"
+	SpecyAttrIntervalType    = "task_interval_type"
+	SpecyAttrIntervalNumber  = "task_interval_number"
+	SpecyAttrCronExpression  = "task_cron_expression"
+	SpecyAttrStartHeight     = "start_height"
+	SpecyAttrEndHeight       = "end_height"
+	SpecyAttrSkipIfRunning   = "task_skip_if_running"
+	SpecyAttrMisfirePolicy   = "task_misfire_policy"
+	SpecyAttrMaxMissedRuns   = "task_max_missed_runs"
+	SpecyAttrEventType       = "task_event_type"
+	SpecyAttrEventPredicates = "task_event_predicates"
+	SpecyAttrDependsOn       = "task_depends_on"
+	SpecyAttrGuard           = "task_guard"
+)
+
+// Presets of the event schemas of the specy task module versions.
+const (
+	// SpecyEventSchemaV1 is the schema of the first versions of the module,
+	// emitting cancle_task events and connect_id attributes.
+	SpecyEventSchemaV1 = "v1"
+	// SpecyEventSchemaV2 is the schema of the module versions emitting the canonical names.
+	SpecyEventSchemaV2 = "v2"
+
+	DefaultSpecyEventSchema = SpecyEventSchemaV1
+)
+
+var specyEventSchemaPresets = map[string]struct{ events, attributes map[string]string }{
+	SpecyEventSchemaV1: {
+		events:     map[string]string{SpecyEventCancelTask: "cancle_task"},
+		attributes: map[string]string{SpecyAttrConnectionID: "connect_id"},
+	},
+	SpecyEventSchemaV2: {},
+}
+
+var specyEventNames = []string{
+	SpecyEventCreateTask, SpecyEventCancelTask, SpecyEventPauseTask, SpecyEventResumeTask, SpecyEventUpdateTask,
+}
+
+var specyAttributeNames = []string{
+	SpecyAttrCreator, SpecyAttrTaskName, SpecyAttrTaskHash, SpecyAttrConnectionID, SpecyAttrMsgs,
+	SpecyAttrRuleFile, SpecyAttrTaskType, SpecyAttrIntervalType, SpecyAttrIntervalNumber, SpecyAttrCronExpression,
+	SpecyAttrStartHeight, SpecyAttrEndHeight, SpecyAttrSkipIfRunning, SpecyAttrMisfirePolicy, SpecyAttrMaxMissedRuns,
+	SpecyAttrEventType, SpecyAttrEventPredicates, SpecyAttrDependsOn, SpecyAttrGuard,
+}
+
+// specyRequiredAttributes holds the attributes without which a task event cannot be applied, by canonical event.
+var specyRequiredAttributes = map[string][]string{
+	SpecyEventCreateTask: {SpecyAttrTaskHash, SpecyAttrCreator, SpecyAttrTaskName, SpecyAttrConnectionID, SpecyAttrIntervalType},
+	SpecyEventCancelTask: {SpecyAttrTaskHash},
+	SpecyEventPauseTask:  {SpecyAttrTaskHash},
+	SpecyEventResumeTask: {SpecyAttrTaskHash},
+	SpecyEventUpdateTask: {SpecyAttrTaskHash},
+}
+
+// SpecyEventSchema maps the canonical names of the specy task events and of their attributes
+// to the names emitted by a version of the specy task module.
+type SpecyEventSchema struct {
+	name string
+
+	// events and attributes map the emitted names to the canonical ones.
+	events     map[string]string
+	attributes map[string]string
+
+	// attributeKeys maps the canonical attribute names to the emitted ones.
+	attributeKeys map[string]string
+}
+
+// NewSpecyEventSchema returns the schema of the preset, with the event types and attribute keys
+// overridden by canonical name. An empty preset selects DefaultSpecyEventSchema.
+func NewSpecyEventSchema(preset string, eventTypes, attributeKeys map[string]string) (*SpecyEventSchema, error) {
+	if preset == "" {
+		preset = DefaultSpecyEventSchema
+	}
+	p, ok := specyEventSchemaPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown specy event schema %q", preset)
+	}
+
+	events, err := resolveSpecyNames("event type", specyEventNames, p.events, eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := resolveSpecyNames("attribute key", specyAttributeNames, p.attributes, attributeKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SpecyEventSchema{
+		name:          preset,
+		events:        make(map[string]string, len(events)),
+		attributes:    make(map[string]string, len(attributes)),
+		attributeKeys: attributes,
+	}
+	for canonical, emitted := range events {
+		if other, ok := s.events[emitted]; ok {
+			return nil, fmt.Errorf("specy events %s and %s are both emitted as %s", other, canonical, emitted)
+		}
+		s.events[emitted] = canonical
+	}
+	for canonical, emitted := range attributes {
+		if other, ok := s.attributes[emitted]; ok {
+			return nil, fmt.Errorf("specy attributes %s and %s are both emitted as %s", other, canonical, emitted)
+		}
+		s.attributes[emitted] = canonical
+	}
+	return s, nil
+}
+
+// resolveSpecyNames maps every canonical name to its emitted name, which defaults to the canonical name.
+func resolveSpecyNames(kind string, canonical []string, preset, overrides map[string]string) (map[string]string, error) {
+	names := make(map[string]string, len(canonical))
+	for _, name := range canonical {
+		names[name] = name
+	}
+	for _, m := range []map[string]string{preset, overrides} {
+		for name, emitted := range m {
+			if _, ok := names[name]; !ok {
+				return nil, fmt.Errorf("unknown specy %s %q", kind, name)
+			}
+			if emitted = strings.TrimSpace(emitted); emitted != "" {
+				names[name] = emitted
+			}
+		}
+	}
+	return names, nil
+}
+
+// Name returns the name of the preset the schema derives from.
+func (s *SpecyEventSchema) Name() string {
+	return s.name
+}
+
+// specyEventFields holds the attributes of a task event by canonical name.
+type specyEventFields struct {
+	schema *SpecyEventSchema
+	event  string
+	values map[string]string
+
+	// unknown holds the sorted keys of the attributes the schema does not know.
+	unknown []string
+}
+
+// get returns the value of the attribute and whether it was set.
+func (f specyEventFields) get(name string) (string, bool) {
+	value, ok := f.values[name]
+	return value, ok
+}
+
+// errorf returns an error about the attribute, named as emitted.
+func (f specyEventFields) errorf(name string, format string, args ...any) error {
+	return fmt.Errorf("%s attribute %s: %s", f.event, f.schema.attributeKeys[name], fmt.Sprintf(format, args...))
+}
+
+// parse returns the canonical name of the event and its attributes, or an empty name for the
+// events that are not specy task events. It fails if a required attribute of the event is missing,
+// listing the attributes the schema does not know, which usually denotes a schema mismatch.
+// The unknown attributes of the events that could be parsed are kept in the fields, see logUnknown.
+func (s *SpecyEventSchema) parse(evt sdk.StringEvent) (string, specyEventFields, error) {
+	event, ok := s.events[evt.Type]
+	if !ok {
+		return "", specyEventFields{}, nil
+	}
+
+	fields := specyEventFields{schema: s, event: evt.Type, values: make(map[string]string, len(evt.Attributes))}
+	var unknown []string
+	for _, attr := range evt.Attributes {
+		name, ok := s.attributes[attr.Key]
+		if !ok {
+			unknown = append(unknown, attr.Key)
+			continue
+		}
+		fields.values[name] = attr.Value
+	}
+
+	sort.Strings(unknown)
+	fields.unknown = unknown
+
+	var missing []string
+	for _, name := range specyRequiredAttributes[event] {
+		if fields.values[name] == "" {
+			missing = append(missing, s.attributeKeys[name])
+		}
+	}
+	if len(missing) == 0 {
+		return event, fields, nil
+	}
+	msg := fmt.Sprintf("%s event misses the required attributes %s of the specy event schema %s",
+		evt.Type, strings.Join(missing, ", "), s.name)
+	if len(unknown) > 0 {
+		msg += fmt.Sprintf(", unknown attributes %s", strings.Join(unknown, ", "))
+	}
+	return event, fields, errors.New(msg)
+}
+
+// logUnknown warns about the attributes of the event the schema does not know,
+// which are ignored and may denote a schema mismatch.
+func (f specyEventFields) logUnknown(log *zap.Logger) {
+	if len(f.unknown) == 0 {
+		return
+	}
+	taskHash, _ := f.get(SpecyAttrTaskHash)
+	log.Warn(
+		"Ignoring unknown attributes of specy task event",
+		zap.String("event_type", f.event),
+		zap.String("task_hash", taskHash),
+		zap.Strings("attributes", f.unknown),
+		zap.String("event_schema", f.schema.name),
+	)
+}
+
+var (
+	specyEventSchemaMu sync.RWMutex
+	specyEventSchema   = mustSpecyEventSchema(DefaultSpecyEventSchema)
+)
+
+func mustSpecyEventSchema(preset string) *SpecyEventSchema {
+	schema, err := NewSpecyEventSchema(preset, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// SetSpecyEventSchema sets the schema the specy task events are parsed with, DefaultSpecyEventSchema until set.
+func SetSpecyEventSchema(schema *SpecyEventSchema) {
+	specyEventSchemaMu.Lock()
+	defer specyEventSchemaMu.Unlock()
+	specyEventSchema = schema
+}
+
+func currentSpecyEventSchema() *SpecyEventSchema {
+	specyEventSchemaMu.RLock()
+	defer specyEventSchemaMu.RUnlock()
+	return specyEventSchema
+}
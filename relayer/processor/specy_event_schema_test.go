@@ -0,0 +1,107 @@
+package processor_test
+
+import (
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func useSpecyEventSchema(t *testing.T, preset string, eventTypes, attributeKeys map[string]string) {
+	schema, err := processor.NewSpecyEventSchema(preset, eventTypes, attributeKeys)
+	require.NoError(t, err)
+	processor.SetSpecyEventSchema(schema)
+	t.Cleanup(func() {
+		defaultSchema, err := processor.NewSpecyEventSchema("", nil, nil)
+		require.NoError(t, err)
+		processor.SetSpecyEventSchema(defaultSchema)
+	})
+}
+
+func TestSpecyEventSchemaPresets(t *testing.T) {
+	v2Event := func(eventType, taskHash string) abci.Event {
+		return renameSpecyAttribute(specyTaskEvent(eventType, taskHash), "connect_id", "connection_id")
+	}
+
+	// v2 spells cancel_task right and names the connection connection_id
+	useSpecyEventSchema(t, processor.SpecyEventSchemaV2, nil, nil)
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+	taskSet.Apply(specyBlockTime, []abci.Event{v2Event("create_task", "a"), v2Event("create_task", "b"), v2Event("cancle_task", "b")}, false)
+	require.Len(t, taskSet.Tasks(), 2)
+	require.Equal(t, "connection-0", taskSet.Tasks()[0].ConnectionId)
+	taskSet.Apply(specyBlockTime, []abci.Event{v2Event("cancel_task", "b")}, false)
+	require.Len(t, taskSet.Tasks(), 1)
+	require.Equal(t, []string{"b"}, taskSet.Cancelled())
+
+	// v1 names the connection connect_id, so the tasks created by the v2 events miss their connection
+	useSpecyEventSchema(t, processor.SpecyEventSchemaV1, nil, nil)
+	taskSet = processor.NewSpecyTaskSet(zap.NewNop())
+	taskSet.Apply(specyBlockTime, []abci.Event{specyTaskEvent("create_task", "a"), v2Event("create_task", "b"), specyTaskEvent("cancle_task", "c")}, false)
+	require.Len(t, taskSet.Tasks(), 1)
+	require.Equal(t, "connection-0", taskSet.Tasks()[0].ConnectionId)
+	require.EqualError(t, taskSet.Rejected()["b"],
+		"create_task event misses the required attributes connect_id of the specy event schema v1, unknown attributes connection_id")
+	require.Equal(t, []string{"c"}, taskSet.Cancelled())
+}
+
+func TestSpecyEventSchemaOverrides(t *testing.T) {
+	useSpecyEventSchema(t, processor.SpecyEventSchemaV2,
+		map[string]string{processor.SpecyEventCreateTask: "task_created"},
+		map[string]string{processor.SpecyAttrTaskHash: "hash"},
+	)
+
+	created := renameSpecyAttribute(specyTaskEvent("task_created", "a"), "task_hash", "hash")
+	created = renameSpecyAttribute(created, "connect_id", "connection_id")
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+	taskSet.Apply(specyBlockTime, []abci.Event{created, specyTaskEvent("create_task", "b")}, false)
+	require.Len(t, taskSet.Tasks(), 1)
+	require.Equal(t, "a", taskSet.Tasks()[0].TaskHash)
+
+	_, err := processor.NewSpecyEventSchema("v3", nil, nil)
+	require.Error(t, err)
+	_, err = processor.NewSpecyEventSchema("", map[string]string{"delete_task": "removed"}, nil)
+	require.Error(t, err)
+	_, err = processor.NewSpecyEventSchema("", nil, map[string]string{processor.SpecyAttrTaskName: "task_hash"})
+	require.Error(t, err)
+}
+
+func TestSpecyEventSchemaRequiredAttributes(t *testing.T) {
+	taskSet := processor.NewSpecyTaskSet(zap.NewNop())
+
+	// an event of another module version misses the attributes of the configured schema
+	mismatched := abci.Event{
+		Type: "create_task",
+		Attributes: []abci.EventAttribute{
+			{Key: "task_hash", Value: "a"},
+			{Key: "owner", Value: "creator"},
+			{Key: "name", Value: "task-a"},
+		},
+	}
+	malformed := specyTaskEvent("create_task", "b")
+	malformed.Attributes = append(malformed.Attributes, abci.EventAttribute{Key: "task_interval_number", Value: "ten"})
+
+	taskSet.Apply(specyBlockTime, []abci.Event{mismatched, malformed}, false)
+	require.Empty(t, taskSet.Tasks())
+	require.EqualError(t, taskSet.Rejected()["a"],
+		"create_task event misses the required attributes creator, task_name, connect_id, task_interval_type of the specy event schema v1, unknown attributes name, owner")
+	require.ErrorContains(t, taskSet.Rejected()["b"], "create_task attribute task_interval_number: invalid value \"ten\"")
+}
+
+func TestSpecyEventSchemaLogsUnknownAttributes(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	taskSet := processor.NewSpecyTaskSet(zap.New(core))
+
+	// the events carrying every required attribute are applied, their unknown attributes ignored
+	create := specyTaskEvent("create_task", "a")
+	create.Attributes = append(create.Attributes, abci.EventAttribute{Key: "task_interval", Value: "10"})
+	taskSet.Apply(specyBlockTime, []abci.Event{create}, false)
+	require.Len(t, taskSet.Tasks(), 1)
+
+	entries := logs.FilterMessage("Ignoring unknown attributes of specy task event").All()
+	require.Len(t, entries, 1)
+	require.Equal(t, "a", entries[0].ContextMap()["task_hash"])
+	require.Equal(t, []interface{}{"task_interval"}, entries[0].ContextMap()["attributes"])
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/cosmos/relayer/v2/relayer/chains/cosmos"
 	penumbraprocessor "github.com/cosmos/relayer/v2/relayer/chains/penumbra"
 	"github.com/cosmos/relayer/v2/relayer/processor"
+	"github.com/cosmos/relayer/v2/specy"
 	"go.uber.org/zap"
 )
 
@@ -43,6 +44,7 @@ func StartRelayer(
 	processorType string,
 	initialBlockHistory uint64,
 	metrics *processor.PrometheusMetrics,
+	specyScheduler *specy.Scheduler,
 ) chan error {
 	errorChan := make(chan error, 1)
 
@@ -51,7 +53,7 @@ func StartRelayer(
 		chainProcessors := make([]processor.ChainProcessor, 0, len(chains))
 
 		for _, chain := range chains {
-			chainProcessors = append(chainProcessors, chain.chainProcessor(log, metrics))
+			chainProcessors = append(chainProcessors, chain.chainProcessor(log, metrics, specyScheduler))
 		}
 
 		ePaths := make([]path, len(paths))
@@ -113,13 +115,14 @@ type path struct {
 }
 
 // chainProcessor returns the corresponding ChainProcessor implementation instance for a pathChain.
-func (chain *Chain) chainProcessor(log *zap.Logger, metrics *processor.PrometheusMetrics) processor.ChainProcessor {
+// The specy scheduler is only handed to cosmos chain processors and may be nil.
+func (chain *Chain) chainProcessor(log *zap.Logger, metrics *processor.PrometheusMetrics, specyScheduler *specy.Scheduler) processor.ChainProcessor {
 	// Handle new ChainProcessor implementations as cases here
 	switch p := chain.ChainProvider.(type) {
 	case *penumbraprocessor.PenumbraProvider:
 		return penumbraprocessor.NewPenumbraChainProcessor(log, p)
 	case *cosmos.CosmosProvider:
-		return cosmos.NewCosmosChainProcessor(log, p, metrics)
+		return cosmos.NewCosmosChainProcessor(log, p, metrics, specyScheduler)
 	default:
 		panic(fmt.Errorf("unsupported chain provider type: %T", chain.ChainProvider))
 	}
@@ -0,0 +1,156 @@
+package specy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// AdminPathPrefix is the path the admin API is served under.
+const AdminPathPrefix = "/specy/"
+
+const defaultAdminHistoryLimit = 20
+
+// adminHandler serves the admin API of a scheduler:
+//
+//	GET  /specy/tasks                 registered tasks with their next activation
+//	GET  /specy/tasks/{hash}?limit=N  a task with its N most recent executions
+//	POST /specy/tasks/{hash}/trigger  executes the task now on this instance
+//	POST /specy/tasks/{hash}/pause    pauses the task on this instance
+//	POST /specy/tasks/{hash}/resume   resumes the task on this instance
+//	POST /specy/queue/drain           drops the executions waiting for a worker
+type adminHandler struct {
+	log       *zap.Logger
+	scheduler *Scheduler
+	token     string
+}
+
+// NewAdminHandler returns the admin API of the scheduler, served under AdminPathPrefix to the requests
+// bearing the token in their Authorization header. Every request is refused when token is empty.
+func NewAdminHandler(log *zap.Logger, scheduler *Scheduler, token string) http.Handler {
+	return &adminHandler{log: log, scheduler: scheduler, token: token}
+}
+
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="specy"`)
+		writeAdminError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, AdminPathPrefix)
+	switch {
+	case path == "tasks":
+		if r.Method != http.MethodGet {
+			writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, h.scheduler.TaskStatuses())
+
+	case path == "queue/drain":
+		if r.Method != http.MethodPost {
+			writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, map[string]int{"dropped": h.scheduler.Drain()})
+
+	case strings.HasPrefix(path, "tasks/"):
+		h.serveTask(w, r, strings.TrimPrefix(path, "tasks/"))
+
+	default:
+		writeAdminError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *adminHandler) serveTask(w http.ResponseWriter, r *http.Request, path string) {
+	taskHash, action, _ := strings.Cut(path, "/")
+	if _, ok := h.scheduler.Get(taskHash); !ok {
+		writeAdminError(w, http.StatusNotFound, "unknown task "+taskHash)
+		return
+	}
+
+	if action == "" {
+		if r.Method != http.MethodGet {
+			writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.showTask(w, r, taskHash)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var err error
+	switch action {
+	case "trigger":
+		err = h.scheduler.TriggerNow(taskHash)
+	case "pause":
+		err = h.scheduler.Pause(taskHash)
+	case "resume":
+		err = h.scheduler.Resume(taskHash)
+	default:
+		writeAdminError(w, http.StatusNotFound, "unknown action "+action)
+		return
+	}
+	if err != nil {
+		writeAdminError(w, http.StatusConflict, err.Error())
+		return
+	}
+	h.log.Info("Admin action applied to task", zap.String("task_hash", taskHash), zap.String("action", action))
+
+	status, _ := h.scheduler.TaskStatus(taskHash)
+	writeAdminJSON(w, http.StatusOK, status)
+}
+
+func (h *adminHandler) showTask(w http.ResponseWriter, r *http.Request, taskHash string) {
+	limit := defaultAdminHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			writeAdminError(w, http.StatusBadRequest, "invalid limit "+v)
+			return
+		}
+	}
+
+	status, ok := h.scheduler.TaskStatus(taskHash)
+	if !ok {
+		writeAdminError(w, http.StatusNotFound, "unknown task "+taskHash)
+		return
+	}
+	executions, err := h.scheduler.History(taskHash, limit)
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if executions == nil {
+		executions = []*ExecutionRecord{}
+	}
+	writeAdminJSON(w, http.StatusOK, struct {
+		TaskStatus
+		Executions []*ExecutionRecord `json:"executions"`
+	}{status, executions})
+}
+
+func (h *adminHandler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func writeAdminJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, code int, msg string) {
+	writeAdminJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,89 @@
+package specy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func adminRequest(t *testing.T, handler http.Handler, method, path, token string, out any) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if out != nil && rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out))
+	}
+	return rec.Code
+}
+
+func TestAdminHandler(t *testing.T) {
+	recorder := new(executionRecorder)
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), specy.NewTaskStore(dbm.NewMemDB()), recorder.execute)
+	handler := specy.NewAdminHandler(zap.NewNop(), scheduler, "secret")
+
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	hourly := specy.NewTask("hourly", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeTimeInterval, 3600, start)
+	fifth := specy.NewTask("fifth", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryNBlocks, 5, time.Time{})
+	require.NoError(t, scheduler.Register(hourly))
+	require.NoError(t, scheduler.Register(fifth))
+	scheduler.TriggerBlockTasks(12, time.Now())
+
+	// access control
+	require.Equal(t, http.StatusUnauthorized, adminRequest(t, handler, http.MethodGet, "/specy/tasks", "", nil))
+	require.Equal(t, http.StatusUnauthorized, adminRequest(t, handler, http.MethodGet, "/specy/tasks", "wrong", nil))
+	require.Equal(t, http.StatusUnauthorized, adminRequest(t, specy.NewAdminHandler(zap.NewNop(), scheduler, ""), http.MethodGet, "/specy/tasks", "", nil))
+
+	// list with the next activations
+	var statuses []specy.TaskStatus
+	require.Equal(t, http.StatusOK, adminRequest(t, handler, http.MethodGet, "/specy/tasks", "secret", &statuses))
+	require.Len(t, statuses, 2)
+	require.Equal(t, "fifth", statuses[0].Task.TaskHash)
+	require.Equal(t, int64(15), statuses[0].NextFireHeight)
+	require.Equal(t, "hourly", statuses[1].Task.TaskHash)
+	require.True(t, start.Equal(*statuses[1].NextFireTime))
+
+	// force-trigger, then show the task with its executions
+	require.Equal(t, http.StatusOK, adminRequest(t, handler, http.MethodPost, "/specy/tasks/hourly/trigger", "secret", nil))
+	require.Equal(t, []string{"hourly"}, recorder.take())
+	var shown struct {
+		specy.TaskStatus
+		Executions []*specy.ExecutionRecord `json:"executions"`
+	}
+	require.Equal(t, http.StatusOK, adminRequest(t, handler, http.MethodGet, "/specy/tasks/hourly?limit=5", "secret", &shown))
+	require.Equal(t, "hourly", shown.Task.TaskHash)
+	require.Len(t, shown.Executions, 1)
+	require.Equal(t, specy.ExecutionStatusSucceeded, shown.Executions[0].Status)
+
+	// pause and resume locally
+	var status specy.TaskStatus
+	require.Equal(t, http.StatusOK, adminRequest(t, handler, http.MethodPost, "/specy/tasks/fifth/pause", "secret", &status))
+	require.True(t, status.Task.Paused)
+	require.Zero(t, status.NextFireHeight)
+	scheduler.TriggerBlockTasks(15, time.Now())
+	require.Empty(t, recorder.take())
+	var resumed specy.TaskStatus
+	require.Equal(t, http.StatusOK, adminRequest(t, handler, http.MethodPost, "/specy/tasks/fifth/resume", "secret", &resumed))
+	require.False(t, resumed.Task.Paused)
+	scheduler.TriggerBlockTasks(20, time.Now())
+	require.Equal(t, []string{"fifth"}, recorder.take())
+
+	var drained map[string]int
+	require.Equal(t, http.StatusOK, adminRequest(t, handler, http.MethodPost, "/specy/queue/drain", "secret", &drained))
+	require.Equal(t, 0, drained["dropped"])
+
+	require.Equal(t, http.StatusNotFound, adminRequest(t, handler, http.MethodGet, "/specy/tasks/unknown", "secret", nil))
+	require.Equal(t, http.StatusNotFound, adminRequest(t, handler, http.MethodPost, "/specy/tasks/hourly/explode", "secret", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, adminRequest(t, handler, http.MethodGet, "/specy/tasks/hourly/trigger", "secret", nil))
+	require.Equal(t, http.StatusBadRequest, adminRequest(t, handler, http.MethodGet, "/specy/tasks/hourly?limit=x", "secret", nil))
+}
@@ -15,6 +15,74 @@ type SpecyConfig struct {
 	TargetChainBinaryLocation string `yaml:"chain_binary_location"`
 	EngineNodeAddress         string `yaml:"engine_node_address"`
 	HomeDir                   string `yaml:"home_dir"`
+
+	// EngineNodeAddresses lists further engine nodes the task requests are balanced across, next to EngineNodeAddress.
+	// The connected nodes are health checked every EngineProbeIntervalSeconds, and pinged after
+	// EngineKeepaliveSeconds without activity.
+	EngineNodeAddresses        []string `yaml:"engine_node_addresses"`
+	EngineProbeIntervalSeconds int      `yaml:"engine_probe_interval_seconds"`
+	EngineKeepaliveSeconds     int      `yaml:"engine_keepalive_seconds"`
+
+	// EngineRequestTimeoutSeconds bounds the wait for the engine response of a task request, which is sent
+	// at most EngineMaxAttempts times when it times out or fails with a retryable code. The circuit of a node
+	// opens after EngineCircuitThreshold consecutive failed requests, failing its requests fast for
+	// EngineCircuitOpenSeconds.
+	EngineRequestTimeoutSeconds int `yaml:"engine_request_timeout_seconds"`
+	EngineMaxAttempts           int `yaml:"engine_max_attempts"`
+	EngineCircuitThreshold      int `yaml:"engine_circuit_threshold"`
+	EngineCircuitOpenSeconds    int `yaml:"engine_circuit_open_seconds"`
+
+	// EngineTLS secures the connections to the engine nodes with TLS, which setting EngineTLSCAFile or
+	// EngineTLSCertFile implies. The engine certificates are verified against the authorities of EngineTLSCAFile,
+	// or the system roots, for EngineTLSServerName if set. EngineTLSCertFile and EngineTLSKeyFile authenticate
+	// the executor to the engine nodes requiring mutual TLS. Modified files are reloaded on the next handshakes.
+	EngineTLS           bool   `yaml:"engine_tls"`
+	EngineTLSCAFile     string `yaml:"engine_tls_ca_file"`
+	EngineTLSCertFile   string `yaml:"engine_tls_cert_file"`
+	EngineTLSKeyFile    string `yaml:"engine_tls_key_file"`
+	EngineTLSServerName string `yaml:"engine_tls_server_name"`
+
+	// ExecutorWorkers and ExecutorQueueSize size the pool running task executions.
+	ExecutorWorkers   int `yaml:"executor_workers"`
+	ExecutorQueueSize int `yaml:"executor_queue_size"`
+
+	// MisfirePolicy and MaxMissedRuns handle the runs missed during downtime, for tasks that do not set their own.
+	MisfirePolicy string `yaml:"misfire_policy"`
+	MaxMissedRuns int    `yaml:"max_missed_runs"`
+
+	// ClockMode is the clock time based tasks are scheduled against, local or chain.
+	ClockMode string `yaml:"clock_mode"`
+
+	// LeaseFile enables leader election among scheduler replicas sharing this file, so that only
+	// the leader executes tasks. ReplicaId identifies this replica and defaults to its host name and pid.
+	LeaseFile       string `yaml:"lease_file"`
+	ReplicaId       string `yaml:"replica_id"`
+	LeaseTTLSeconds int    `yaml:"lease_ttl_seconds"`
+
+	// ShardMembers splits the tasks among the listed replica ids with a consistent-hash ring.
+	// With ShardDir set, the members are instead discovered from the heartbeats of the replicas
+	// sharing this directory, and the tasks rebalanced as replicas join or leave. The tasks linked by
+	// depends_on are owned by the replica owning their root task.
+	ShardMembers      []string `yaml:"shard_members"`
+	ShardDir          string   `yaml:"shard_dir"`
+	ShardVirtualNodes int      `yaml:"shard_virtual_nodes"`
+	ShardTTLSeconds   int      `yaml:"shard_ttl_seconds"`
+
+	// HistoryMaxRecords and HistoryRetentionHours bound the execution history kept per task.
+	HistoryMaxRecords     int `yaml:"history_max_records"`
+	HistoryRetentionHours int `yaml:"history_retention_hours"`
+	// ExecutionLedgerRetentionHours bounds how long completed executions are remembered to skip their replays.
+	ExecutionLedgerRetentionHours int `yaml:"execution_ledger_retention_hours"`
+
+	// AdminToken enables the scheduler admin API on the debug server, for the requests bearing this token.
+	AdminToken string `yaml:"admin_token"`
+
+	// EventSchema selects the preset naming the task events and their attributes, following the version
+	// of the specy task module, v1 or v2. EventTypes and EventAttributes override single names of the preset,
+	// keyed by their canonical name, e.g. cancel_task or connection_id.
+	EventSchema     string            `yaml:"event_schema"`
+	EventTypes      map[string]string `yaml:"event_types"`
+	EventAttributes map[string]string `yaml:"event_attributes"`
 }
 
 func ReadSpecyConfig() {
@@ -0,0 +1,211 @@
+package specy
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// maxPendingSlots bounds the number of slots a dependent task waits on its parents for.
+// Older slots are dropped, e.g. when an execution of a parent was never triggered.
+const maxPendingSlots = 100
+
+const rejectReasonDependencyFailed = "dependency_failed"
+
+// pendingSlot tracks the parents of a dependent task that succeeded for a slot.
+type pendingSlot struct {
+	trigger   Trigger
+	succeeded map[string]bool
+}
+
+// slotKey identifies the slot of a trigger, shared by a parent and its dependents.
+func slotKey(trigger Trigger) string {
+	return strconv.FormatInt(trigger.Height, 10) + "/" + strconv.FormatInt(trigger.Time.UnixNano(), 10)
+}
+
+// checkDependencies rejects a task whose dependencies would form a cycle
+// with the registered tasks. The caller must hold s.mu.
+func (s *Scheduler) checkDependencies(task *Task) error {
+	if task.Condition.IntervalType == IntervalTypeDependent && len(task.DependsOn) == 0 {
+		return fmt.Errorf("depends_on task without dependencies")
+	}
+	if task.Condition.IntervalType != IntervalTypeDependent && len(task.DependsOn) > 0 {
+		return fmt.Errorf("task with dependencies must use the %s interval type", IntervalTypeDependent)
+	}
+	seen := make(map[string]bool)
+	for _, parent := range task.DependsOn {
+		if seen[parent] {
+			return fmt.Errorf("duplicate dependency %s", parent)
+		}
+		seen[parent] = true
+	}
+
+	visited := make(map[string]bool)
+	var visit func(hash string, path []string) error
+	visit = func(hash string, path []string) error {
+		if hash == task.TaskHash && len(path) > 0 {
+			return fmt.Errorf("dependency cycle %v", append(path, hash))
+		}
+		if visited[hash] {
+			return nil
+		}
+		visited[hash] = true
+
+		parents := task.DependsOn
+		if hash != task.TaskHash {
+			parent, ok := s.tasks[hash]
+			if !ok {
+				// dependencies may be registered later
+				return nil
+			}
+			parents = parent.DependsOn
+		}
+		for _, parent := range parents {
+			if err := visit(parent, append(path, hash)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(task.TaskHash, nil)
+}
+
+// completeDependencies triggers the dependents of the task once all their parents succeeded for the slot
+// of the trigger. When the execution of the task failed, its dependents are skipped for the slot,
+// and so are their own dependents.
+func (s *Scheduler) completeDependencies(task *Task, trigger Trigger, execErr error) {
+	key := slotKey(trigger)
+
+	s.mu.Lock()
+	var ready, failed []*Task
+	for _, dependent := range s.tasks {
+		if dependent.Paused || !dependsOn(dependent, task.TaskHash) {
+			continue
+		}
+
+		slots := s.pending[dependent.TaskHash]
+		if execErr != nil {
+			delete(slots, key)
+			failed = append(failed, dependent)
+			continue
+		}
+
+		if slots == nil {
+			slots = make(map[string]*pendingSlot)
+			s.pending[dependent.TaskHash] = slots
+		}
+		slot, ok := slots[key]
+		if !ok {
+			pruneOldestSlot(slots)
+			slot = &pendingSlot{trigger: trigger, succeeded: make(map[string]bool)}
+			slots[key] = slot
+		}
+		slot.succeeded[task.TaskHash] = true
+		if len(slot.succeeded) == len(dependent.DependsOn) {
+			delete(slots, key)
+			ready = append(ready, dependent)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, dependent := range ready {
+		s.dispatch(dependent, trigger)
+	}
+	for _, dependent := range failed {
+		s.log.Warn(
+			"Skipping dependent task, dependency failed",
+			zap.String("task_hash", dependent.TaskHash),
+			zap.String("dependency", task.TaskHash),
+			zap.Error(execErr),
+		)
+		s.metrics.IncExecutionsRejected(rejectReasonDependencyFailed)
+		s.completeDependencies(dependent, trigger, fmt.Errorf("dependency %s failed: %w", task.TaskHash, execErr))
+	}
+}
+
+// updateShardKeys groups the tasks linked by dependencies, which are owned by the owner of the first root task
+// of their group in hash order, so that a dependent task runs on the replica completing its parents.
+// The caller must hold s.mu.
+func (s *Scheduler) updateShardKeys() {
+	// union-find over the task hashes linked by dependencies, including the parents not registered yet
+	groups := make(map[string]string)
+	find := func(hash string) string {
+		for groups[hash] != hash {
+			hash = groups[hash]
+		}
+		return hash
+	}
+	for hash, task := range s.tasks {
+		for _, parent := range task.DependsOn {
+			for _, h := range []string{hash, parent} {
+				if _, ok := groups[h]; !ok {
+					groups[h] = h
+				}
+			}
+			if a, b := find(hash), find(parent); a != b {
+				groups[a] = b
+			}
+		}
+	}
+
+	roots := make(map[string]string, len(groups))
+	for hash := range groups {
+		if task, ok := s.tasks[hash]; ok && len(task.DependsOn) > 0 {
+			continue
+		}
+		group := find(hash)
+		if root, ok := roots[group]; !ok || hash < root {
+			roots[group] = hash
+		}
+	}
+
+	s.shardKeys = make(map[string]string, len(groups))
+	for hash := range groups {
+		if root, ok := roots[find(hash)]; ok {
+			s.shardKeys[hash] = root
+		}
+	}
+}
+
+// owns reports whether this replica executes the task.
+func (s *Scheduler) owns(taskHash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ownsLocked(taskHash)
+}
+
+// ownsLocked reports whether this replica executes the task. The caller must hold s.mu.
+func (s *Scheduler) ownsLocked(taskHash string) bool {
+	if s.ownership == nil {
+		return true
+	}
+	if key, ok := s.shardKeys[taskHash]; ok {
+		taskHash = key
+	}
+	return s.ownership.Owns(taskHash)
+}
+
+func dependsOn(task *Task, parent string) bool {
+	for _, hash := range task.DependsOn {
+		if hash == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneOldestSlot drops the oldest slot when the dependent already waits on maxPendingSlots slots.
+func pruneOldestSlot(slots map[string]*pendingSlot) {
+	if len(slots) < maxPendingSlots {
+		return
+	}
+	var oldest string
+	for key, slot := range slots {
+		if oldest == "" || slot.trigger.Time.Before(slots[oldest].trigger.Time) ||
+			(slot.trigger.Time.Equal(slots[oldest].trigger.Time) && slot.trigger.Height < slots[oldest].trigger.Height) {
+			oldest = key
+		}
+	}
+	delete(slots, oldest)
+}
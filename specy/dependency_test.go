@@ -0,0 +1,66 @@
+package specy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func dependentTask(hash string, dependsOn ...string) *specy.Task {
+	task := specy.NewTask(hash, "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeDependent, 0, time.Time{})
+	task.DependsOn = dependsOn
+	return task
+}
+
+func TestDependencyChain(t *testing.T) {
+	recorder := &executionRecorder{failing: make(map[string]bool)}
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorder.execute)
+
+	// snapshot -> rewards -> set-rewards, and set-rewards also waits on prices
+	require.NoError(t, scheduler.Register(specy.NewTask("snapshot", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryNBlocks, 10, time.Time{})))
+	require.NoError(t, scheduler.Register(specy.NewTask("prices", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryNBlocks, 10, time.Time{})))
+	require.NoError(t, scheduler.Register(dependentTask("rewards", "snapshot")))
+	require.NoError(t, scheduler.Register(dependentTask("set-rewards", "rewards", "prices")))
+
+	scheduler.TriggerBlockTasks(10, time.Time{})
+	executed := recorder.take()
+	require.ElementsMatch(t, []string{"snapshot", "prices", "rewards", "set-rewards"}, executed)
+	require.Less(t, indexOf(executed, "snapshot"), indexOf(executed, "rewards"))
+	require.Less(t, indexOf(executed, "rewards"), indexOf(executed, "set-rewards"))
+	require.Less(t, indexOf(executed, "prices"), indexOf(executed, "set-rewards"))
+
+	// a failure propagates down the chain for the slot
+	recorder.failing["snapshot"] = true
+	scheduler.TriggerBlockTasks(20, time.Time{})
+	require.ElementsMatch(t, []string{"snapshot", "prices"}, recorder.take())
+
+	// and does not leak into the next slot
+	recorder.failing["snapshot"] = false
+	scheduler.TriggerBlockTasks(30, time.Time{})
+	require.Len(t, recorder.take(), 4)
+}
+
+func TestDependencyCycleRejected(t *testing.T) {
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, nil)
+
+	require.NoError(t, scheduler.Register(dependentTask("a", "b")))
+	require.NoError(t, scheduler.Register(dependentTask("b", "c")))
+	require.Error(t, scheduler.Register(dependentTask("c", "a")))
+	require.Error(t, scheduler.Register(dependentTask("d", "d")))
+	require.Error(t, scheduler.Register(dependentTask("e", "a", "a")))
+	require.Error(t, scheduler.Register(dependentTask("f")))
+	require.NoError(t, scheduler.Register(dependentTask("c", "g")))
+}
+
+func indexOf(hashes []string, hash string) int {
+	for i, h := range hashes {
+		if h == hash {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,86 @@
+package specy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Predicate operators of on_event task conditions.
+const (
+	PredicateOpEq       = "eq"
+	PredicateOpNeq      = "neq"
+	PredicateOpContains = "contains"
+	PredicateOpPrefix   = "prefix"
+	PredicateOpExists   = "exists"
+)
+
+// Event is a chain event handed to the scheduler to trigger on_event tasks.
+type Event struct {
+	Type       string           `json:"type"`
+	Attributes []EventAttribute `json:"attributes"`
+	// Index is the position of the event among the events of its block.
+	Index int `json:"index"`
+}
+
+type EventAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// EventPredicate holds for an event with an attribute of the given key whose value satisfies the operator.
+// An empty operator means eq.
+type EventPredicate struct {
+	Key   string `json:"key"`
+	Op    string `json:"op,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// matches reports whether the value of an attribute with the predicate key satisfies the predicate.
+func (p EventPredicate) matches(value string) bool {
+	switch p.Op {
+	case PredicateOpEq, "":
+		return value == p.Value
+	case PredicateOpNeq:
+		return value != p.Value
+	case PredicateOpContains:
+		return strings.Contains(value, p.Value)
+	case PredicateOpPrefix:
+		return strings.HasPrefix(value, p.Value)
+	case PredicateOpExists:
+		return true
+	default:
+		return false
+	}
+}
+
+func validateEventPredicate(p EventPredicate) error {
+	if p.Key == "" {
+		return fmt.Errorf("event predicate without attribute key")
+	}
+	switch p.Op {
+	case PredicateOpEq, "", PredicateOpNeq, PredicateOpContains, PredicateOpPrefix, PredicateOpExists:
+		return nil
+	default:
+		return fmt.Errorf("unsupported event predicate operator %q", p.Op)
+	}
+}
+
+// matchesEvent reports whether the event has the condition event type and satisfies every predicate.
+func (c Condition) matchesEvent(event Event) bool {
+	if event.Type != c.EventType {
+		return false
+	}
+	for _, predicate := range c.EventPredicates {
+		matched := false
+		for _, attr := range event.Attributes {
+			if attr.Key == predicate.Key && predicate.matches(attr.Value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package specy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const rejectReasonDuplicate = "duplicate"
+
+// ExecutionID returns the deterministic ID of the execution of the task for the slot of the trigger:
+// its height and scheduled time, and the position of the triggering event in the block for on_event tasks.
+// Executions of the same task for the same slot share an ID, e.g. when a block is processed again.
+func ExecutionID(taskHash string, trigger Trigger) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s/%d/%s", taskHash, trigger.Height, trigger.Time.UTC().Format(time.RFC3339Nano))
+	if trigger.Event != nil {
+		fmt.Fprintf(h, "/%d", trigger.Event.Index)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// startExecution claims the execution with the given ID of the task.
+// It returns false if the execution is already running or has completed.
+func (s *Scheduler) startExecution(taskHash, executionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running[executionID] {
+		s.log.Info("Task execution already running, skipping", zap.String("task_hash", taskHash), zap.String("execution_id", executionID))
+		s.metrics.IncExecutionsRejected(rejectReasonDuplicate)
+		return false
+	}
+	if s.store != nil {
+		completed, err := s.store.ExecutionCompleted(taskHash, executionID)
+		if err != nil {
+			s.log.Error("Failed to check execution ledger", zap.String("task_hash", taskHash), zap.Error(err))
+		}
+		if completed {
+			s.log.Info("Task execution already completed, skipping", zap.String("task_hash", taskHash), zap.String("execution_id", executionID))
+			s.metrics.IncExecutionsRejected(rejectReasonDuplicate)
+			return false
+		}
+	}
+
+	s.running[executionID] = true
+	return true
+}
+
+// finishExecution releases the execution with the given ID, recording it as completed if it succeeded.
+func (s *Scheduler) finishExecution(taskHash, executionID string, succeeded bool) {
+	if succeeded && s.store != nil {
+		if err := s.store.SetExecutionCompleted(taskHash, executionID, time.Now()); err != nil {
+			s.log.Error("Failed to record completed execution", zap.String("task_hash", taskHash), zap.Error(err))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, executionID)
+}
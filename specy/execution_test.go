@@ -0,0 +1,59 @@
+package specy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExecutionID(t *testing.T) {
+	slot := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	id := specy.ExecutionID("a", specy.Trigger{Height: 10, Time: slot})
+	require.Equal(t, id, specy.ExecutionID("a", specy.Trigger{Height: 10, Time: slot.In(time.FixedZone("UTC+8", 8*60*60))}))
+	require.NotEqual(t, id, specy.ExecutionID("b", specy.Trigger{Height: 10, Time: slot}))
+	require.NotEqual(t, id, specy.ExecutionID("a", specy.Trigger{Height: 11, Time: slot}))
+	require.NotEqual(t, id, specy.ExecutionID("a", specy.Trigger{Height: 10, Time: slot.Add(time.Second)}))
+
+	first := specy.ExecutionID("a", specy.Trigger{Height: 10, Time: slot, Event: &specy.Event{Type: "transfer", Index: 1}})
+	second := specy.ExecutionID("a", specy.Trigger{Height: 10, Time: slot, Event: &specy.Event{Type: "transfer", Index: 2}})
+	require.NotEqual(t, first, second)
+}
+
+func TestSchedulerSkipsCompletedExecutions(t *testing.T) {
+	store := specy.NewTaskStore(dbm.NewMemDB())
+	recorder := &executionRecorder{failing: make(map[string]bool)}
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), store, recorder.execute)
+	require.NoError(t, scheduler.Register(specy.NewTask("a", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})))
+
+	blockTime := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	// a block processed again does not execute the task twice
+	scheduler.TriggerBlockTasks(10, blockTime)
+	scheduler.TriggerBlockTasks(10, blockTime)
+	require.Equal(t, []string{"a"}, recorder.take())
+
+	// a failed execution is not recorded and can be retried
+	recorder.failing["a"] = true
+	scheduler.TriggerBlockTasks(11, blockTime.Add(5*time.Second))
+	recorder.failing["a"] = false
+	scheduler.TriggerBlockTasks(11, blockTime.Add(5*time.Second))
+	require.Equal(t, []string{"a", "a"}, recorder.take())
+
+	// the ledger survives restarts
+	restarted := specy.NewScheduler(context.Background(), zap.NewNop(), store, recorder.execute)
+	require.NoError(t, restarted.Load())
+	restarted.TriggerBlockTasks(10, blockTime)
+	require.Empty(t, recorder.take())
+
+	// and is cleared with the task
+	restarted.Unregister("a")
+	completed, err := store.ExecutionCompleted("a", specy.ExecutionID("a", specy.Trigger{Height: 10, Time: blockTime}))
+	require.NoError(t, err)
+	require.False(t, completed)
+}
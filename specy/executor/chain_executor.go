@@ -2,6 +2,7 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -14,17 +15,47 @@ import (
 	"time"
 )
 
-func SendTaskResponseToChain(specyResp specytypes.TaskResponse, task *specy.Task) error {
-	taskResult := string(specyResp.Result.TaskResult)
+// txConfirmAttempts and txConfirmInterval bound the wait for a task response transaction to be included in a block.
+var (
+	txConfirmAttempts = 10
+	txConfirmInterval = 2 * time.Second
+)
+
+// TxResult is the outcome of a task response transaction.
+type TxResult struct {
+	TxHash string
+	// Confirmed reports whether the transaction was found in a block, with its Height, Code and GasUsed.
+	// Before that, Code is the result of the transaction checks on broadcast.
+	Confirmed bool
+	Height    int64
+	Code      uint32
+	GasUsed   int64
+	RawLog    string
+}
+
+// txResponse is the JSON output of the chain binary for a transaction.
+type txResponse struct {
+	Height  string `json:"height"`
+	TxHash  string `json:"txhash"`
+	Code    uint32 `json:"code"`
+	RawLog  string `json:"raw_log"`
+	GasUsed string `json:"gas_used"`
+}
+
+// SendTaskResponseToChain submits the engine response of the task execution scheduled at triggerTime,
+// and waits for the transaction to be included in a block. The transaction result is returned as soon
+// as the transaction was broadcast, including when it failed or could not be confirmed.
+func SendTaskResponseToChain(ctx context.Context, specyResp *specytypes.TaskResponse, task *specy.Task, triggerTime time.Time) (*TxResult, error) {
+	taskResult := string(specyResp.Result.GetTaskResult())
 	//taskResult := "FM2vKqiPHN0XCQ=="
 	//taskResult, _ = decodeTaskResult(taskResult)
-	//completeCalldata, err := assembleCalldata(task.RuleFile, taskResult)
-	_, err := assembleCalldata(task.RuleFile, taskResult)
+	//completeCalldata, err := assembleCalldata(task.RuleFile, taskResult, triggerTime)
+	_, err := assembleCalldata(task.RuleFile, taskResult, triggerTime)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	cmd := exec.Command(specyconfig.Config.TargetChainBinaryLocation, "tx", "specy", "execute-task", task.Creator, task.TaskName, string(specyResp.Signature), string(specyResp.Result.TaskResult), "--from", task.Creator, "--chain_id", specyconfig.Config.TargetChainId, "--home", specyconfig.Config.HomeDir, "--keyring-backend", "test", "--yes")
+	cmd := exec.CommandContext(ctx, specyconfig.Config.TargetChainBinaryLocation, "tx", "specy", "execute-task", task.Creator, task.TaskName, string(specyResp.Signature), taskResult, "--from", task.Creator, "--chain_id", specyconfig.Config.TargetChainId, "--home", specyconfig.Config.HomeDir, "--keyring-backend", "test", "--yes", "--output", "json")
 
 	// 创建缓冲区来存储标准输出和标准错误输出
 	var stdout bytes.Buffer
@@ -42,17 +73,71 @@ func SendTaskResponseToChain(specyResp specytypes.TaskResponse, task *specy.Task
 		fmt.Println("执行命令出错:", err)
 		fmt.Println("标准输出:", stdout.String())
 		fmt.Println("标准错误输出:", stderr.String())
-		return err
+		return nil, err
 	}
 
 	// 执行成功
 	fmt.Println("命令执行完成")
 	fmt.Println("标准输出:", stdout.String())
 	fmt.Println("标准错误输出:", stderr.String())
-	return nil
+
+	tx, err := parseTxResponse(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if tx.Code != 0 {
+		return tx, fmt.Errorf("transaction %s rejected with code %d: %s", tx.TxHash, tx.Code, tx.RawLog)
+	}
+	return confirmTx(ctx, tx)
+}
+
+// confirmTx waits for the broadcast transaction to be included in a block, and returns its on-chain result.
+// An unconfirmed transaction is returned as is once the attempts are exhausted, without error.
+func confirmTx(ctx context.Context, tx *TxResult) (*TxResult, error) {
+	for attempt := 0; attempt < txConfirmAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return tx, ctx.Err()
+		case <-time.After(txConfirmInterval):
+		}
+
+		cmd := exec.CommandContext(ctx, specyconfig.Config.TargetChainBinaryLocation, "query", "tx", tx.TxHash, "--home", specyconfig.Config.HomeDir, "--output", "json")
+		out, err := cmd.Output()
+		if err != nil {
+			// not included yet
+			continue
+		}
+		confirmed, err := parseTxResponse(out)
+		if err != nil {
+			return tx, err
+		}
+		confirmed.Confirmed = true
+		if confirmed.Code != 0 {
+			return confirmed, fmt.Errorf("transaction %s failed with code %d: %s", confirmed.TxHash, confirmed.Code, confirmed.RawLog)
+		}
+		return confirmed, nil
+	}
+	return tx, nil
+}
+
+func parseTxResponse(out []byte) (*TxResult, error) {
+	var resp txResponse
+	if err := json.Unmarshal(bytes.TrimSpace(out), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction response: %w", err)
+	}
+	tx := &TxResult{TxHash: resp.TxHash, Code: resp.Code, RawLog: resp.RawLog}
+	if resp.Height != "" {
+		tx.Height, _ = strconv.ParseInt(resp.Height, 10, 64)
+	}
+	if resp.GasUsed != "" {
+		tx.GasUsed, _ = strconv.ParseInt(resp.GasUsed, 10, 64)
+	}
+	return tx, nil
 }
 
-func assembleCalldata(calldata string, taskResult string) (string, error) {
+// assembleCalldata fills the calldata with the task result and the day of the execution trigger time,
+// so that every executor submits the same calldata for the same activation.
+func assembleCalldata(calldata string, taskResult string, triggerTime time.Time) (string, error) {
 	// 解析 JSON 字符串
 	var data Data
 	err := json.Unmarshal([]byte(calldata), &data)
@@ -68,8 +153,7 @@ func assembleCalldata(calldata string, taskResult string) (string, error) {
 
 	// 提取 value 值
 	values := make([]string, len(data.Params))
-	currentTime := time.Now()
-	truncatedTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, currentTime.Location())
+	truncatedTime := time.Date(triggerTime.Year(), triggerTime.Month(), triggerTime.Day(), 0, 0, 0, 0, triggerTime.Location())
 	timestamp := truncatedTime.Unix()
 	values[0] = strconv.FormatInt(timestamp, 10)
 	values[1] = taskResult
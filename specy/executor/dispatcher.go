@@ -1,23 +1,49 @@
 package executor
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/cosmos/relayer/v2/specy"
-	"log"
 )
 
-func ExecuteTask(task *specy.Task) {
+// ExecuteTask invokes the specy engine with the task and submits the engine response to the chain,
+// filling in the engine and transaction details of the execution record.
+func ExecuteTask(ctx context.Context, task *specy.Task, trigger specy.Trigger, record *specy.ExecutionRecord) error {
 
 	// invoke specy engine
-	taskResponse, err := InvokeEngineWithTask(task.TaskHash)
+	engineStart := time.Now()
+	taskResponse, err := InvokeEngineWithTask(ctx, task.TaskHash, trigger)
+	record.EngineLatency = time.Since(engineStart)
 	if err != nil {
-		log.Fatal(err)
-		return
+		var engineErr *EngineError
+		if errors.As(err, &engineErr) {
+			record.EngineFailure = &specy.EngineFailure{
+				Code:        engineErr.Code.String(),
+				Attempts:    engineErr.Attempts,
+				CircuitOpen: errors.Is(err, ErrEngineCircuitOpen),
+			}
+		}
+		return fmt.Errorf("failed to invoke engine: %w", err)
+	}
+	if result := taskResponse.Result; result != nil {
+		record.EngineStatus = result.Status
+		record.EngineError = result.ErrorInfo
 	}
 
 	// send task response to chain
-	SendTaskResponseToChain(taskResponse, task)
+	tx, err := SendTaskResponseToChain(ctx, taskResponse, task, trigger.Time)
+	if tx != nil {
+		record.TxHash = tx.TxHash
+		record.TxConfirmed = tx.Confirmed
+		record.TxHeight = tx.Height
+		record.TxCode = tx.Code
+		record.GasUsed = tx.GasUsed
+	}
 	if err != nil {
-		log.Fatal(err)
-		return
+		return fmt.Errorf("failed to send task response to chain: %w", err)
 	}
+	return nil
 }
@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultEngineCircuitThreshold    = 5
+	DefaultEngineCircuitOpenDuration = 30 * time.Second
+)
+
+// States of the circuit breakers of the engine nodes.
+const (
+	EngineCircuitClosed = "closed"
+	// EngineCircuitOpen nodes are not sent requests until the open duration elapsed.
+	EngineCircuitOpen = "open"
+	// EngineCircuitHalfOpen nodes are sent a single trial request, which closes the circuit if answered.
+	EngineCircuitHalfOpen = "half-open"
+)
+
+// ErrEngineCircuitOpen is returned for the task requests while the circuits of every ready engine node are open.
+var ErrEngineCircuitOpen = errors.New("specy engine circuit open")
+
+// circuitBreaker stops sending requests to an engine node after consecutive failed requests,
+// until the node answers a trial request once the circuit has been open for long enough.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	// trial is set while the trial request of the half-open circuit is in flight.
+	trial bool
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{state: EngineCircuitClosed}
+}
+
+// available reports whether a request could be sent to the node.
+func (b *circuitBreaker) available(now time.Time, openDuration time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case EngineCircuitOpen:
+		return now.Sub(b.openedAt) >= openDuration
+	case EngineCircuitHalfOpen:
+		return !b.trial
+	default:
+		return true
+	}
+}
+
+// acquire reserves the sending of a request to the node, which must be followed by success, failure or release.
+// The first request once the open duration elapsed is the trial request of the half-open circuit.
+func (b *circuitBreaker) acquire(now time.Time, openDuration time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case EngineCircuitOpen:
+		if now.Sub(b.openedAt) < openDuration {
+			return false
+		}
+		b.state, b.trial = EngineCircuitHalfOpen, true
+	case EngineCircuitHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+	}
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state, b.failures, b.trial = EngineCircuitClosed, 0, false
+}
+
+// failure opens the circuit after threshold consecutive failures, or when the trial request failed.
+func (b *circuitBreaker) failure(now time.Time, threshold int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == EngineCircuitHalfOpen || b.failures >= threshold {
+		b.state, b.openedAt, b.trial = EngineCircuitOpen, now, false
+	}
+}
+
+// release gives up a request that neither succeeded nor failed because of the node.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *circuitBreaker) current() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
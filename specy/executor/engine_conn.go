@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+const engineDialTimeout = 10 * time.Second
+
+// DefaultEngineKeepalive pings the engine nodes after a minute without activity,
+// and closes the connections whose pings are not acknowledged within 20 seconds.
+var DefaultEngineKeepalive = keepalive.ClientParameters{
+	Time:                time.Minute,
+	Timeout:             20 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// EngineConn is the connection of a task result stream to an engine node.
+type EngineConn interface {
+	io.Closer
+	// CheckHealth fails if the engine node does not serve task requests.
+	CheckHealth(ctx context.Context) error
+}
+
+// EngineDialer opens a task result stream to the engine node at addr, for the lifetime of ctx.
+type EngineDialer func(ctx context.Context, addr string) (types.Regulator_GetTaskResultClient, EngineConn, error)
+
+// NewEngineDialer returns the EngineDialer connecting to the engine nodes over grpc with the keepalive parameters,
+// secured with tlsConfig unless nil.
+func NewEngineDialer(tlsConfig *tls.Config, keepaliveParams keepalive.ClientParameters) EngineDialer {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	return func(ctx context.Context, addr string) (types.Regulator_GetTaskResultClient, EngineConn, error) {
+		dialCtx, cancel := context.WithTimeout(ctx, engineDialTimeout)
+		defer cancel()
+		conn, err := grpc.DialContext(
+			dialCtx,
+			addr,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithKeepaliveParams(keepaliveParams),
+			grpc.WithBlock(),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		stream, err := types.NewRegulatorClient(conn).GetTaskResult(ctx)
+		if err != nil {
+			_ = conn.Close()
+			return nil, nil, err
+		}
+		return stream, &grpcEngineConn{conn: conn}, nil
+	}
+}
+
+type grpcEngineConn struct {
+	conn *grpc.ClientConn
+}
+
+func (c *grpcEngineConn) Close() error {
+	return c.conn.Close()
+}
+
+// CheckHealth checks the Regulator service with the grpc health checking protocol, or the whole server
+// when the service is not known by the health service. The engine nodes without health service are only
+// checked for the state of their connection.
+func (c *grpcEngineConn) CheckHealth(ctx context.Context) error {
+	switch state := c.conn.GetState(); state {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return fmt.Errorf("connection is %s", state)
+	}
+
+	client := healthpb.NewHealthClient(c.conn)
+	res, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: types.Regulator_ServiceDesc.ServiceName})
+	if status.Code(err) == codes.NotFound {
+		res, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	}
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		return nil
+	case err != nil:
+		return err
+	case res.Status != healthpb.HealthCheckResponse_SERVING:
+		return fmt.Errorf("engine is %s", res.Status)
+	}
+	return nil
+}
@@ -2,102 +2,63 @@ package executor
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"github.com/cosmos/relayer/v2/specy"
 	specyconfig "github.com/cosmos/relayer/v2/specy/config"
 	"github.com/cosmos/relayer/v2/specy/types"
-	"google.golang.org/grpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
 	"log"
-	"sync"
 	"time"
 )
 
-var engineStream types.Regulator_GetTaskResultClient
+// enginePool balances the task requests across the connected engine nodes.
+var enginePool *EnginePool
 
-type Heartbeat struct {
-	isConnected bool
-	mutex       sync.Mutex
-}
-
-var instance *Heartbeat
-var once sync.Once
-
-func ConnectSpecyEngineWithHeartbeat(ctx context.Context) {
-	// 创建并缓存长连接
-	createAndCacheEngineStream(ctx, false)
-
-	// 启动心跳检测
-	go func() {
-		heartbeat := getHeartbeat()
-		heartbeat.start(ctx, 5*time.Second)
-	}()
-}
-
-func getHeartbeat() *Heartbeat {
-	once.Do(func() {
-		instance = &Heartbeat{isConnected: true}
-	})
-	return instance
-}
-
-func (hb *Heartbeat) start(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval) // 每隔interval时间发送一次心跳请求
-
-	for range ticker.C {
-		if hb.isConnected {
-			// 发送心跳请求并获取响应
-			_, err := engineStream.Recv()
-			if err == nil {
-				return
-			}
-			fmt.Println("\nGRPC stream 心跳请求无响应...")
-		}
-
-		// 如果连接中断，进行相应处理
-		fmt.Println("GRPC stream 开始重连...")
-
-		// 加锁以保证线程安全
-		hb.mutex.Lock()
-		hb.isConnected = false
-
-		// 重新创建并缓存长连接
-		stream := createAndCacheEngineStream(ctx, true)
-		if stream != nil {
-			hb.isConnected = true
-		}
-		// 解锁
-		hb.mutex.Unlock()
+// ConnectSpecyEngine connects to the engine nodes of the specy config, and keeps them connected
+// until ctx is done. The returned pool reports the state of the nodes to metrics.
+func ConnectSpecyEngine(ctx context.Context, log *zap.Logger, metrics *specy.Metrics) (*EnginePool, error) {
+	cfg := specyconfig.Config
+	addrs := cfg.EngineNodeAddresses
+	if cfg.EngineNodeAddress != "" {
+		addrs = append([]string{cfg.EngineNodeAddress}, addrs...)
 	}
-}
 
-func createAndCacheEngineStream(ctx context.Context, isHeartbeat bool) types.Regulator_GetTaskResultClient {
-	engineNodeAddress := specyconfig.Config.EngineNodeAddress
-	fmt.Printf("-------------engineNodeAddress: %s \n", engineNodeAddress)
-
-	clientCon, err := grpc.Dial(engineNodeAddress, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(10*time.Second))
-	if err != nil {
-		if !isHeartbeat {
-			log.Fatalf("Failed to connnect engine: %v \n", err)
-			return nil
+	var tlsConfig *tls.Config
+	if cfg.EngineTLS || cfg.EngineTLSCAFile != "" || cfg.EngineTLSCertFile != "" {
+		var err error
+		tlsConfig, err = NewEngineTLSConfig(log, EngineTLSConfig{
+			CAFile:     cfg.EngineTLSCAFile,
+			CertFile:   cfg.EngineTLSCertFile,
+			KeyFile:    cfg.EngineTLSKeyFile,
+			ServerName: cfg.EngineTLSServerName,
+		})
+		if err != nil {
+			return nil, err
 		}
-		log.Printf("Failed to connnect engine: %v \n", err)
-		return nil
+	}
+	keepaliveParams := DefaultEngineKeepalive
+	if cfg.EngineKeepaliveSeconds > 0 {
+		keepaliveParams.Time = time.Duration(cfg.EngineKeepaliveSeconds) * time.Second
 	}
 
-	client := types.NewRegulatorClient(clientCon)
-	fmt.Printf("-------------client: %+v \n", client)
-
-	stream, err := client.GetTaskResult(ctx)
-	fmt.Printf("-------------stream: %+v \n", stream)
-
+	pool, err := NewEnginePool(log, addrs, NewEngineDialer(tlsConfig, keepaliveParams), time.Duration(cfg.EngineProbeIntervalSeconds)*time.Second)
 	if err != nil {
-		log.Fatalf("Failed to create grpc stream with engine: %v", err)
-		return nil
+		return nil, err
+	}
+	pool.SetMetrics(metrics)
+	pool.SetRequestTimeout(time.Duration(cfg.EngineRequestTimeoutSeconds) * time.Second)
+	pool.SetRetry(cfg.EngineMaxAttempts, 0, 0)
+	pool.SetCircuitBreaker(cfg.EngineCircuitThreshold, time.Duration(cfg.EngineCircuitOpenSeconds)*time.Second)
+	pool.Start(ctx)
+	if len(pool.Available()) == 0 {
+		log.Warn("No specy engine node reachable yet, task requests fail until one is connected", zap.Strings("addrs", addrs))
 	}
 
-	// 保存stream连接到全局变量
-	engineStream = stream
-
-	return stream
+	// 保存连接池到全局变量
+	enginePool = pool
+	return pool, nil
 }
 
 func InvokeEngineWithTx(
@@ -141,28 +102,44 @@ func InvokeEngineWithTx(
 	return cproof, err
 }
 
-func InvokeEngineWithTask(taskHash string) (types.TaskResponse, error) {
+// InvokeEngineWithTask requests the engine result of a task execution.
+// The chain event that triggered an on_event task is handed to the engine with the request.
+func InvokeEngineWithTask(ctx context.Context, taskHash string, trigger specy.Trigger) (*types.TaskResponse, error) {
 	// 构建请求
 	request := &types.TaskRequest{
 		Taskhash: []byte(taskHash),
 	}
+	if trigger.Event != nil {
+		request.TriggerEvent = &types.TriggerEvent{
+			Type:       trigger.Event.Type,
+			Attributes: make([]*types.EventAttribute, len(trigger.Event.Attributes)),
+			Height:     trigger.Height,
+		}
+		for i, attr := range trigger.Event.Attributes {
+			request.TriggerEvent.Attributes[i] = &types.EventAttribute{Key: attr.Key, Value: attr.Value}
+		}
+	}
 
-	response, err := SendTaskRequest(*request)
+	response, err := SendTaskRequest(ctx, request)
 	return response, err
 }
 
-func SendTaskRequest(request types.TaskRequest) (types.TaskResponse, error) {
+// SendTaskRequest sends the request over the engine stream and waits for its response,
+// retrying the failed attempts. The returned errors are *EngineError.
+func SendTaskRequest(ctx context.Context, request *types.TaskRequest) (*types.TaskResponse, error) {
 	log.Default().Println("开始engine逻辑")
 	// 获取缓存的stream
-	stream := engineStream
-	stream.Send(&request)
-	resp, err := stream.Recv()
+	if enginePool == nil {
+		return nil, &EngineError{Code: codes.Unavailable, Err: ErrNoEngineAvailable}
+	}
+
+	resp, err := enginePool.Do(ctx, request)
 	fmt.Printf("-------------resp: %+v \n", resp)
 
 	if err != nil {
-		return types.TaskResponse{}, err
+		return nil, err
 	}
-	return *resp, nil
+	return resp, nil
 }
 
 /** ---------------------------------- deprecated functions ---------------------------------- */
@@ -0,0 +1,502 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/cosmos/relayer/v2/specy/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultEngineHealthCheckInterval = 5 * time.Second
+	DefaultEngineMinBackoff          = time.Second
+	DefaultEngineMaxBackoff          = time.Minute
+
+	engineHealthCheckTimeout = 5 * time.Second
+)
+
+// States of the engine nodes.
+const (
+	EngineNodeConnecting = "connecting"
+	EngineNodeReady      = "ready"
+	// EngineNodeDown nodes could not be reached or failed, and are connected again after a backoff.
+	EngineNodeDown = "down"
+)
+
+// ErrNoEngineAvailable is returned for the task requests while no engine node is connected.
+var ErrNoEngineAvailable = errors.New("no specy engine node available")
+
+// EnginePool balances the task requests across the streams of several engine nodes, sending each request
+// to the ready node with the fewest requests in flight, in turn among equals.
+// A node is ejected as soon as its stream fails or its health check fails, and connected again after
+// a jittered exponential backoff. The requests that time out or fail with a retryable code are sent again,
+// to another node if any, and the nodes failing consecutive requests are skipped while their circuit is open.
+type EnginePool struct {
+	log                 *zap.Logger
+	dial                EngineDialer
+	healthCheckInterval time.Duration
+	minBackoff          time.Duration
+	maxBackoff          time.Duration
+	metrics             *specy.Metrics
+	nodes               []*engineNode
+
+	requestTimeout      time.Duration
+	maxAttempts         int
+	retryMinBackoff     time.Duration
+	retryMaxBackoff     time.Duration
+	circuitThreshold    int
+	circuitOpenDuration time.Duration
+
+	// next rotates the node the search for the least busy node starts from.
+	next atomic.Uint64
+}
+
+type engineNode struct {
+	addr     string
+	inflight atomic.Int64
+	breaker  *circuitBreaker
+
+	mu        sync.Mutex
+	mux       *StreamMux
+	conn      EngineConn
+	state     string
+	since     time.Time
+	failures  int
+	lastError string
+}
+
+// EngineNodeStatus describes the connection to an engine node.
+type EngineNodeStatus struct {
+	Address string    `json:"address"`
+	State   string    `json:"state"`
+	Since   time.Time `json:"since"`
+	// Inflight is the number of requests waiting for the response of the node.
+	Inflight int64 `json:"inflight"`
+	// Failures is the number of consecutive failed connections to the node.
+	Failures  int    `json:"failures"`
+	LastError string `json:"last_error,omitempty"`
+	// Circuit is the state of the circuit breaker of the node.
+	Circuit string `json:"circuit"`
+}
+
+// NewEnginePool returns the pool of the engine nodes at addrs, which connects to them once started.
+// The ready nodes are health checked every healthCheckInterval, DefaultEngineHealthCheckInterval if not positive.
+func NewEnginePool(log *zap.Logger, addrs []string, dial EngineDialer, healthCheckInterval time.Duration) (*EnginePool, error) {
+	if healthCheckInterval <= 0 {
+		healthCheckInterval = DefaultEngineHealthCheckInterval
+	}
+
+	p := &EnginePool{
+		log:                 log,
+		dial:                dial,
+		healthCheckInterval: healthCheckInterval,
+		minBackoff:          DefaultEngineMinBackoff,
+		maxBackoff:          DefaultEngineMaxBackoff,
+		nodes:               make([]*engineNode, 0, len(addrs)),
+		requestTimeout:      DefaultEngineRequestTimeout,
+		maxAttempts:         DefaultEngineMaxAttempts,
+		retryMinBackoff:     DefaultEngineRetryMinBackoff,
+		retryMaxBackoff:     DefaultEngineRetryMaxBackoff,
+		circuitThreshold:    DefaultEngineCircuitThreshold,
+		circuitOpenDuration: DefaultEngineCircuitOpenDuration,
+	}
+	seen := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		p.nodes = append(p.nodes, &engineNode{addr: addr, breaker: newCircuitBreaker(), state: EngineNodeConnecting, since: time.Now()})
+	}
+	if len(p.nodes) == 0 {
+		return nil, errors.New("no specy engine node address configured")
+	}
+	return p, nil
+}
+
+// SetMetrics sets the metrics the state of the nodes is reported to.
+func (p *EnginePool) SetMetrics(metrics *specy.Metrics) {
+	p.metrics = metrics
+	for _, node := range p.nodes {
+		node.mu.Lock()
+		metrics.SetEngineNodeState(node.addr, node.state)
+		node.mu.Unlock()
+	}
+}
+
+// SetReconnectBackoff bounds the delay before connecting again to a node, which doubles with every
+// consecutive failure from min up to max. A non positive value keeps the current bound.
+func (p *EnginePool) SetReconnectBackoff(min, max time.Duration) {
+	if min > 0 {
+		p.minBackoff = min
+	}
+	if max > 0 {
+		p.maxBackoff = max
+	}
+}
+
+// SetRequestTimeout bounds the wait for the response of every attempt of a task request.
+// A non positive value keeps the current bound.
+func (p *EnginePool) SetRequestTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		p.requestTimeout = timeout
+	}
+}
+
+// SetRetry bounds the attempts of a task request to maxAttempts, waiting between attempts a delay
+// doubling from minBackoff up to maxBackoff. A non positive value keeps the current bound.
+func (p *EnginePool) SetRetry(maxAttempts int, minBackoff, maxBackoff time.Duration) {
+	if maxAttempts > 0 {
+		p.maxAttempts = maxAttempts
+	}
+	if minBackoff > 0 {
+		p.retryMinBackoff = minBackoff
+	}
+	if maxBackoff > 0 {
+		p.retryMaxBackoff = maxBackoff
+	}
+}
+
+// SetCircuitBreaker opens the circuit of a node after threshold consecutive failed requests, for openDuration
+// before a trial request. A non positive value keeps the current setting.
+func (p *EnginePool) SetCircuitBreaker(threshold int, openDuration time.Duration) {
+	if threshold > 0 {
+		p.circuitThreshold = threshold
+	}
+	if openDuration > 0 {
+		p.circuitOpenDuration = openDuration
+	}
+}
+
+// Start connects to the engine nodes, then keeps every node connected until ctx is done,
+// when the connections are closed. The nodes that cannot be reached at start are down until
+// they are connected again.
+func (p *EnginePool) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, node := range p.nodes {
+		wg.Add(1)
+		go func(node *engineNode) {
+			p.connect(ctx, node)
+			wg.Done()
+			p.run(ctx, node)
+		}(node)
+	}
+	wg.Wait()
+}
+
+// run health checks the node while it is ready, and connects it again after a backoff once down.
+func (p *EnginePool) run(ctx context.Context, node *engineNode) {
+	defer node.close()
+	for {
+		if mux, conn := node.current(); mux != nil {
+			p.watch(ctx, node, mux, conn)
+		}
+
+		node.mu.Lock()
+		delay := backoff(node.failures, p.minBackoff, p.maxBackoff)
+		node.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		p.connect(ctx, node)
+	}
+}
+
+// watch returns once the stream of the node failed or its health check failed, or ctx is done.
+func (p *EnginePool) watch(ctx context.Context, node *engineNode, mux *StreamMux, conn EngineConn) {
+	ticker := time.NewTicker(p.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-mux.Done():
+			p.eject(node, mux, mux.Err())
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, engineHealthCheckTimeout)
+			err := conn.CheckHealth(checkCtx)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				p.eject(node, mux, fmt.Errorf("health check failed: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// backoff returns the delay following the given consecutive failures, doubling from min up to max,
+// jittered over the upper half of the exponential delay.
+func backoff(failures int, min, max time.Duration) time.Duration {
+	delay := min
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// connect dials the node, which is ready once its health check succeeds.
+func (p *EnginePool) connect(ctx context.Context, node *engineNode) {
+	p.setState(node, EngineNodeConnecting, nil)
+	stream, conn, err := p.dial(ctx, node.addr)
+	if err == nil {
+		checkCtx, cancel := context.WithTimeout(ctx, engineHealthCheckTimeout)
+		if err = conn.CheckHealth(checkCtx); err != nil {
+			_ = conn.Close()
+			err = fmt.Errorf("health check failed: %w", err)
+		}
+		cancel()
+	}
+	if err != nil {
+		node.mu.Lock()
+		node.failures++
+		failures := node.failures
+		node.mu.Unlock()
+		p.log.Warn(
+			"Failed to connect specy engine node",
+			zap.String("addr", node.addr),
+			zap.Int("consecutive_failures", failures),
+			zap.Error(err),
+		)
+		p.setState(node, EngineNodeDown, err)
+		return
+	}
+
+	node.mu.Lock()
+	node.release()
+	node.mux, node.conn = NewStreamMux(p.log.With(zap.String("addr", node.addr)), stream), conn
+	node.failures = 0
+	node.mu.Unlock()
+	p.log.Info("Connected specy engine node", zap.String("addr", node.addr))
+	p.setState(node, EngineNodeReady, nil)
+}
+
+// eject closes the stream of the node, unless it was replaced already.
+func (p *EnginePool) eject(node *engineNode, mux *StreamMux, err error) {
+	node.mu.Lock()
+	if node.mux != mux {
+		node.mu.Unlock()
+		return
+	}
+	node.release()
+	node.failures++
+	node.mu.Unlock()
+
+	p.log.Warn("Ejecting specy engine node", zap.String("addr", node.addr), zap.Error(err))
+	p.setState(node, EngineNodeDown, err)
+}
+
+func (p *EnginePool) setState(node *engineNode, state string, err error) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if node.state != state {
+		node.state, node.since = state, time.Now()
+	}
+	if err != nil {
+		node.lastError = err.Error()
+	}
+	p.metrics.SetEngineNodeState(node.addr, state)
+}
+
+// Do sends the request to the least busy ready node and waits for its response. The request is sent again
+// after a backoff when the attempt timed out or failed with a retryable code, preferably to a node not tried yet,
+// for at most maxAttempts attempts or until ctx is done. No engine node being ready counts as a failed attempt. The returned errors are *EngineError.
+func (p *EnginePool) Do(ctx context.Context, request *types.TaskRequest) (*types.TaskResponse, error) {
+	tried := make(map[*engineNode]bool, len(p.nodes))
+	attempts := 0
+	for try := 1; ; try++ {
+		response, sent, err := p.attempt(ctx, request, tried)
+		if sent {
+			attempts++
+		}
+		if err == nil {
+			return response, nil
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if ctx.Err() != nil || !retryableEngineError(err) || try >= p.maxAttempts {
+			return nil, &EngineError{Code: engineErrorCode(err), Attempts: attempts, Err: err}
+		}
+
+		delay := backoff(try, p.retryMinBackoff, p.retryMaxBackoff)
+		p.log.Warn(
+			"Specy engine request failed, sending it again",
+			zap.String("task_hash", string(request.Taskhash)),
+			zap.String("request_id", request.RequestId),
+			zap.Int("attempt", try),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil, &EngineError{Code: engineErrorCode(ctx.Err()), Attempts: attempts, Err: ctx.Err()}
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attempt sends the request to a node and waits for its response for at most requestTimeout,
+// reporting whether the request was sent to a node.
+func (p *EnginePool) attempt(ctx context.Context, request *types.TaskRequest, tried map[*engineNode]bool) (*types.TaskResponse, bool, error) {
+	node, mux, err := p.pick(tried)
+	if err != nil && len(tried) > 0 {
+		// every node available was tried already
+		node, mux, err = p.pick(nil)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	tried[node] = true
+
+	attemptCtx, cancel := context.WithTimeout(ctx, p.requestTimeout)
+	defer cancel()
+	node.inflight.Add(1)
+	response, err := mux.Do(attemptCtx, request)
+	node.inflight.Add(-1)
+	switch {
+	case err == nil:
+		node.breaker.success()
+		return response, true, nil
+	case ctx.Err() != nil:
+		// given up by the caller, not failed by the node
+		node.breaker.release()
+		return nil, true, ctx.Err()
+	}
+
+	node.breaker.failure(time.Now(), p.circuitThreshold)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = status.Errorf(codes.DeadlineExceeded, "no response within %s", p.requestTimeout)
+	}
+	return nil, true, fmt.Errorf("engine node %s: %w", node.addr, err)
+}
+
+// Available returns the addresses of the ready nodes.
+func (p *EnginePool) Available() []string {
+	var addrs []string
+	for _, node := range p.nodes {
+		if mux, _ := node.current(); mux != nil {
+			addrs = append(addrs, node.addr)
+		}
+	}
+	return addrs
+}
+
+// Statuses returns the status of every node.
+func (p *EnginePool) Statuses() []EngineNodeStatus {
+	statuses := make([]EngineNodeStatus, len(p.nodes))
+	for i, node := range p.nodes {
+		node.mu.Lock()
+		statuses[i] = EngineNodeStatus{
+			Address:   node.addr,
+			State:     node.state,
+			Since:     node.since,
+			Inflight:  node.inflight.Load(),
+			Failures:  node.failures,
+			LastError: node.lastError,
+			Circuit:   node.breaker.current(),
+		}
+		node.mu.Unlock()
+	}
+	return statuses
+}
+
+// EngineStatusPath is the path the status of the engine nodes is served at.
+const EngineStatusPath = "/specy/engines"
+
+// NewEngineStatusHandler returns the handler serving the status of the nodes of the pool in json.
+func NewEngineStatusHandler(pool *EnginePool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(pool.Statuses())
+	})
+}
+
+// pick returns the ready node with the fewest requests in flight among the nodes not excluded whose circuit
+// is not open, and reserves the request to the node. It fails with ErrEngineCircuitOpen when the circuits
+// of every ready node are open, and ErrNoEngineAvailable when no node is ready.
+func (p *EnginePool) pick(excluded map[*engineNode]bool) (*engineNode, *StreamMux, error) {
+	for {
+		now := time.Now()
+		start := int(p.next.Add(1) % uint64(len(p.nodes)))
+		var best *engineNode
+		var bestMux *StreamMux
+		open := false
+		for i := range p.nodes {
+			node := p.nodes[(start+i)%len(p.nodes)]
+			if excluded[node] {
+				continue
+			}
+			mux, _ := node.current()
+			if mux == nil {
+				continue
+			}
+			if !node.breaker.available(now, p.circuitOpenDuration) {
+				open = true
+				continue
+			}
+			if best == nil || node.inflight.Load() < best.inflight.Load() {
+				best, bestMux = node, mux
+			}
+		}
+		switch {
+		case best != nil:
+			if best.breaker.acquire(now, p.circuitOpenDuration) {
+				return best, bestMux, nil
+			}
+			// the trial request of the node was reserved concurrently
+		case open:
+			return nil, nil, ErrEngineCircuitOpen
+		default:
+			return nil, nil, ErrNoEngineAvailable
+		}
+	}
+}
+
+// current returns the stream multiplexer and the connection of the node, nil while the node is not ready.
+func (n *engineNode) current() (*StreamMux, EngineConn) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.mux == nil || n.mux.Err() != nil {
+		return nil, nil
+	}
+	return n.mux, n.conn
+}
+
+func (n *engineNode) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.release()
+}
+
+// release closes the stream and the connection of the node. n.mu must be held.
+func (n *engineNode) release() {
+	if n.mux != nil {
+		_ = n.mux.Close()
+		n.mux = nil
+	}
+	if n.conn != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+	}
+}
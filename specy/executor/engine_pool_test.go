@@ -0,0 +1,249 @@
+package executor_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/cosmos/relayer/v2/specy/executor"
+	"github.com/cosmos/relayer/v2/specy/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakeEngineNodes dials fake engine streams, failing for the nodes that are down.
+type fakeEngineNodes struct {
+	mu        sync.Mutex
+	down      map[string]bool
+	unhealthy map[string]bool
+	streams   map[string]*fakeEngineStream
+}
+
+func newFakeEngineNodes(down ...string) *fakeEngineNodes {
+	n := &fakeEngineNodes{down: make(map[string]bool), unhealthy: make(map[string]bool), streams: make(map[string]*fakeEngineStream)}
+	for _, addr := range down {
+		n.down[addr] = true
+	}
+	return n
+}
+
+func (n *fakeEngineNodes) dial(_ context.Context, addr string) (types.Regulator_GetTaskResultClient, executor.EngineConn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.down[addr] {
+		return nil, nil, errors.New("connection refused")
+	}
+	stream := newFakeEngineStream()
+	n.streams[addr] = stream
+	return stream, fakeEngineConn{nodes: n, addr: addr}, nil
+}
+
+func (n *fakeEngineNodes) setDown(addr string, down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down[addr] = down
+}
+
+func (n *fakeEngineNodes) setUnhealthy(addr string, unhealthy bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.unhealthy[addr] = unhealthy
+}
+
+func (n *fakeEngineNodes) stream(addr string) *fakeEngineStream {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.streams[addr]
+}
+
+// answer answers the next request sent to the node.
+func (n *fakeEngineNodes) answer(t *testing.T, addr string) {
+	stream := n.stream(addr)
+	request := stream.nextRequest(t)
+	stream.responses <- &types.TaskResponse{Taskhash: request.Taskhash, RequestId: request.RequestId, Signature: []byte(addr)}
+}
+
+// fakeEngineConn fails its health check while its node is unhealthy.
+type fakeEngineConn struct {
+	nodes *fakeEngineNodes
+	addr  string
+}
+
+func (c fakeEngineConn) Close() error {
+	return nil
+}
+
+func (c fakeEngineConn) CheckHealth(context.Context) error {
+	c.nodes.mu.Lock()
+	defer c.nodes.mu.Unlock()
+	if c.nodes.unhealthy[c.addr] {
+		return errors.New("NOT_SERVING")
+	}
+	return nil
+}
+
+func poolDoAsync(ctx context.Context, pool *executor.EnginePool, taskHash string) <-chan muxResult {
+	results := make(chan muxResult, 1)
+	go func() {
+		response, err := pool.Do(ctx, &types.TaskRequest{Taskhash: []byte(taskHash)})
+		results <- muxResult{response, err}
+	}()
+	return results
+}
+
+func TestEnginePoolBalancesRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newFakeEngineNodes()
+	pool, err := executor.NewEnginePool(zap.NewNop(), []string{"engine-0", "engine-1", "engine-0"}, nodes.dial, time.Hour)
+	require.NoError(t, err)
+	pool.Start(ctx)
+	require.ElementsMatch(t, []string{"engine-0", "engine-1"}, pool.Available())
+
+	// the requests in flight are spread across the nodes
+	var results []<-chan muxResult
+	for i := 0; i < 4; i++ {
+		results = append(results, poolDoAsync(ctx, pool, "task"))
+		require.Eventually(t, func() bool {
+			return len(nodes.stream("engine-0").requests)+len(nodes.stream("engine-1").requests) == i+1
+		}, time.Second, time.Millisecond)
+	}
+	require.Len(t, nodes.stream("engine-0").requests, 2)
+	require.Len(t, nodes.stream("engine-1").requests, 2)
+
+	for i := 0; i < 2; i++ {
+		nodes.answer(t, "engine-0")
+		nodes.answer(t, "engine-1")
+	}
+	for _, result := range results {
+		res := <-result
+		require.NoError(t, res.err)
+	}
+}
+
+func TestEnginePoolFailsOver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newFakeEngineNodes("engine-1")
+	pool, err := executor.NewEnginePool(zap.NewNop(), []string{"engine-0", "engine-1"}, nodes.dial, time.Hour)
+	require.NoError(t, err)
+	pool.SetReconnectBackoff(5*time.Millisecond, 20*time.Millisecond)
+
+	// a node unreachable at start is added once reachable
+	pool.Start(ctx)
+	require.Equal(t, []string{"engine-0"}, pool.Available())
+	nodes.setDown("engine-1", false)
+	require.Eventually(t, func() bool { return len(pool.Available()) == 2 }, time.Second, 5*time.Millisecond)
+
+	// the request of a failing node is sent again to another node, and the failing node ejected
+	result := poolDoAsync(ctx, pool, "task")
+	var failing, other string
+	require.Eventually(t, func() bool {
+		switch {
+		case len(nodes.stream("engine-0").requests) == 1:
+			failing, other = "engine-0", "engine-1"
+		case len(nodes.stream("engine-1").requests) == 1:
+			failing, other = "engine-1", "engine-0"
+		}
+		return failing != ""
+	}, time.Second, time.Millisecond)
+	nodes.setDown(failing, true)
+	nodes.stream(failing).fail()
+	nodes.answer(t, other)
+
+	res := <-result
+	require.NoError(t, res.err)
+	require.Equal(t, other, string(res.response.Signature))
+	require.Equal(t, []string{other}, pool.Available())
+
+	// the ejected node comes back once reachable
+	nodes.setDown(failing, false)
+	require.Eventually(t, func() bool { return len(pool.Available()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestEnginePoolHealthCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := specy.NewMetrics(prometheus.NewRegistry())
+	nodes := newFakeEngineNodes()
+	pool, err := executor.NewEnginePool(zap.NewNop(), []string{"engine-0", "engine-1"}, nodes.dial, 5*time.Millisecond)
+	require.NoError(t, err)
+	pool.SetMetrics(metrics)
+	pool.SetReconnectBackoff(5*time.Millisecond, 20*time.Millisecond)
+	pool.Start(ctx)
+	require.Len(t, pool.Available(), 2)
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.EngineNodeState.WithLabelValues("engine-0", executor.EngineNodeReady)))
+
+	// a node failing its health check is ejected
+	nodes.setUnhealthy("engine-0", true)
+	require.Eventually(t, func() bool {
+		return len(pool.Available()) == 1 && pool.Available()[0] == "engine-1"
+	}, time.Second, time.Millisecond)
+	status := pool.Statuses()[0]
+	require.Equal(t, "engine-0", status.Address)
+	require.NotEqual(t, executor.EngineNodeReady, status.State)
+	require.Contains(t, status.LastError, "health check failed")
+
+	// and comes back once healthy
+	nodes.setUnhealthy("engine-0", false)
+	require.Eventually(t, func() bool { return len(pool.Available()) == 2 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, executor.EngineNodeReady, pool.Statuses()[0].State)
+	// a single state is reported per node
+	require.Equal(t, 2, testutil.CollectAndCount(metrics.EngineNodeState))
+}
+
+func TestEngineGRPCHealthCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	healthServer := health.NewServer()
+	server := grpc.NewServer()
+	types.RegisterRegulatorServer(server, echoEngine{})
+	healthpb.RegisterHealthServer(server, healthServer)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(ln) }()
+	defer server.Stop()
+
+	pool, err := executor.NewEnginePool(zap.NewNop(), []string{ln.Addr().String()}, executor.NewEngineDialer(nil, executor.DefaultEngineKeepalive), 5*time.Millisecond)
+	require.NoError(t, err)
+	pool.SetReconnectBackoff(5*time.Millisecond, 20*time.Millisecond)
+	pool.Start(ctx)
+	require.Len(t, pool.Available(), 1)
+
+	healthServer.SetServingStatus(types.Regulator_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	require.Eventually(t, func() bool { return len(pool.Available()) == 0 }, time.Second, time.Millisecond)
+
+	healthServer.SetServingStatus(types.Regulator_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	require.Eventually(t, func() bool { return len(pool.Available()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	_, err = pool.Do(ctx, &types.TaskRequest{Taskhash: []byte("task")})
+	require.NoError(t, err)
+}
+
+func TestEnginePoolWithoutNodes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := executor.NewEnginePool(zap.NewNop(), nil, newFakeEngineNodes().dial, 0)
+	require.Error(t, err)
+
+	pool, err := executor.NewEnginePool(zap.NewNop(), []string{"engine-0"}, newFakeEngineNodes("engine-0").dial, time.Hour)
+	require.NoError(t, err)
+	pool.Start(ctx)
+	require.Empty(t, pool.Available())
+
+	_, err = pool.Do(ctx, &types.TaskRequest{Taskhash: []byte("task")})
+	require.ErrorIs(t, err, executor.ErrNoEngineAvailable)
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultEngineRequestTimeout  = 60 * time.Second
+	DefaultEngineMaxAttempts     = 3
+	DefaultEngineRetryMinBackoff = 200 * time.Millisecond
+	DefaultEngineRetryMaxBackoff = 5 * time.Second
+)
+
+// EngineError is the failure of a task request to the engine, after every attempt.
+type EngineError struct {
+	// Code is the grpc code of the last failed attempt.
+	Code codes.Code
+	// Attempts is the number of times the request was sent, 0 when no engine node could be sent the request.
+	Attempts int
+	Err      error
+}
+
+func (e *EngineError) Error() string {
+	return fmt.Sprintf("engine request failed after %d attempts (%s): %v", e.Attempts, e.Code, e.Err)
+}
+
+func (e *EngineError) Unwrap() error {
+	return e.Err
+}
+
+// engineErrorCode returns the grpc code of the failure of a task request.
+func engineErrorCode(err error) codes.Code {
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	switch {
+	case errors.As(err, &grpcErr):
+		return grpcErr.GRPCStatus().Code()
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, ErrNoEngineAvailable), errors.Is(err, ErrEngineCircuitOpen),
+		errors.Is(err, ErrStreamClosed), errors.Is(err, io.EOF):
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// retryableEngineError reports whether a failed task request may succeed if sent again.
+// The requests failing fast on open circuits are not retried.
+func retryableEngineError(err error) bool {
+	if errors.Is(err, ErrEngineCircuitOpen) {
+		return false
+	}
+	switch engineErrorCode(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
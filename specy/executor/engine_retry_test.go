@@ -0,0 +1,93 @@
+package executor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/cosmos/relayer/v2/specy/executor"
+	"github.com/cosmos/relayer/v2/specy/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+func TestEnginePoolRetriesTimedOutRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newFakeEngineNodes()
+	pool, err := executor.NewEnginePool(zap.NewNop(), []string{"engine-0"}, nodes.dial, time.Hour)
+	require.NoError(t, err)
+	pool.SetRequestTimeout(50 * time.Millisecond)
+	pool.SetRetry(3, time.Millisecond, 5*time.Millisecond)
+	pool.Start(ctx)
+
+	// the request is sent again once the first attempt timed out
+	result := poolDoAsync(ctx, pool, "task")
+	nodes.stream("engine-0").nextRequest(t)
+	nodes.answer(t, "engine-0")
+	res := <-result
+	require.NoError(t, res.err)
+	require.Equal(t, "task", string(res.response.Taskhash))
+
+	// until the attempts are exhausted
+	result = poolDoAsync(ctx, pool, "task")
+	for i := 0; i < 3; i++ {
+		nodes.stream("engine-0").nextRequest(t)
+	}
+	res = <-result
+	var engineErr *executor.EngineError
+	require.ErrorAs(t, res.err, &engineErr)
+	require.Equal(t, codes.DeadlineExceeded, engineErr.Code)
+	require.Equal(t, 3, engineErr.Attempts)
+}
+
+func TestEnginePoolCircuitBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newFakeEngineNodes()
+	pool, err := executor.NewEnginePool(zap.NewNop(), []string{"engine-0"}, nodes.dial, time.Hour)
+	require.NoError(t, err)
+	pool.SetRequestTimeout(20 * time.Millisecond)
+	pool.SetRetry(1, 0, 0)
+	pool.SetCircuitBreaker(2, 100*time.Millisecond)
+	pool.Start(ctx)
+
+	// consecutive timed out requests open the circuit
+	for i := 0; i < 2; i++ {
+		_, err := pool.Do(ctx, &types.TaskRequest{Taskhash: []byte("task")})
+		require.Error(t, err)
+		nodes.stream("engine-0").nextRequest(t)
+	}
+	require.Equal(t, executor.EngineCircuitOpen, pool.Statuses()[0].Circuit)
+
+	// the requests fail fast while the circuit is open
+	_, err = pool.Do(ctx, &types.TaskRequest{Taskhash: []byte("task")})
+	var engineErr *executor.EngineError
+	require.ErrorAs(t, err, &engineErr)
+	require.ErrorIs(t, err, executor.ErrEngineCircuitOpen)
+	require.Equal(t, codes.Unavailable, engineErr.Code)
+	require.Zero(t, engineErr.Attempts)
+	require.Empty(t, nodes.stream("engine-0").requests)
+
+	// an answered trial request closes the circuit
+	time.Sleep(100 * time.Millisecond)
+	result := poolDoAsync(ctx, pool, "task")
+	require.Eventually(t, func() bool {
+		return pool.Statuses()[0].Circuit == executor.EngineCircuitHalfOpen
+	}, time.Second, time.Millisecond)
+	nodes.answer(t, "engine-0")
+	require.NoError(t, (<-result).err)
+	require.Equal(t, executor.EngineCircuitClosed, pool.Statuses()[0].Circuit)
+}
+
+func TestExecuteTaskRecordsEngineFailure(t *testing.T) {
+	// no engine is connected
+	record := &specy.ExecutionRecord{}
+	err := executor.ExecuteTask(context.Background(), &specy.Task{TaskHash: "task"}, specy.Trigger{Time: time.Now()}, record)
+	require.ErrorIs(t, err, executor.ErrNoEngineAvailable)
+	require.Equal(t, &specy.EngineFailure{Code: codes.Unavailable.String()}, record.EngineFailure)
+}
@@ -0,0 +1,157 @@
+package executor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EngineTLSConfig holds the files securing the connections to the engine nodes with TLS.
+type EngineTLSConfig struct {
+	// CAFile is the PEM bundle of the certificate authorities the engine certificates are verified against.
+	// The system roots are used when empty.
+	CAFile string
+	// CertFile and KeyFile are the PEM certificate and key of the executor, presented to the engine nodes
+	// requiring mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name the engine certificates are verified for, which defaults to the host
+	// of the engine node address.
+	ServerName string
+}
+
+// NewEngineTLSConfig returns the client TLS configuration of the connections to the engine nodes.
+// The files are read again on the handshakes following their modification, so that certificates
+// can be rotated without restart. The established connections keep the certificates they were
+// negotiated with. The files that fail to reload are reported to log.
+func NewEngineTLSConfig(log *zap.Logger, cfg EngineTLSConfig) (*tls.Config, error) {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("engine tls client certificate and key must be set together")
+	}
+
+	r := &certReloader{log: log, cfg: cfg}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+	if cfg.CAFile != "" {
+		// the certificate chain is verified by verifyConnection against the reloaded authorities
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = r.verifyConnection
+	}
+	if cfg.CertFile != "" {
+		tlsConfig.GetClientCertificate = r.clientCertificate
+	}
+	return tlsConfig, nil
+}
+
+// certReloader holds the certificates of an EngineTLSConfig, reloaded when their files are modified.
+type certReloader struct {
+	log *zap.Logger
+	cfg EngineTLSConfig
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+	roots    *x509.CertPool
+	cert     *tls.Certificate
+}
+
+// reload reads the files modified since they were last read.
+func (r *certReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes := make(map[string]time.Time, 3)
+	changed := r.modTimes == nil
+	for _, file := range []string{r.cfg.CAFile, r.cfg.CertFile, r.cfg.KeyFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var roots *x509.CertPool
+	if r.cfg.CAFile != "" {
+		bz, err := os.ReadFile(r.cfg.CAFile)
+		if err != nil {
+			return err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(bz) {
+			return fmt.Errorf("no certificate found in engine tls ca file %s", r.cfg.CAFile)
+		}
+	}
+	var cert *tls.Certificate
+	if r.cfg.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load engine tls client certificate: %w", err)
+		}
+		cert = &c
+	}
+
+	r.modTimes, r.roots, r.cert = modTimes, roots, cert
+	return nil
+}
+
+func (r *certReloader) current() (*x509.CertPool, *tls.Certificate) {
+	if err := r.reload(); err != nil {
+		// a file being rewritten may be momentarily invalid, the certificates last loaded stay in use
+		r.log.Warn(
+			"Failed to reload specy engine tls certificates",
+			zap.String("ca_file", r.cfg.CAFile),
+			zap.String("cert_file", r.cfg.CertFile),
+			zap.Error(err),
+		)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.roots, r.cert
+}
+
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	_, cert := r.current()
+	return cert, nil
+}
+
+// verifyConnection verifies the certificate chain of the engine against the certificate authorities.
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	roots, _ := r.current()
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("engine presented no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	if r.cfg.ServerName != "" {
+		opts.DNSName = r.cfg.ServerName
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
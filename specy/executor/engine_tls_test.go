@@ -0,0 +1,211 @@
+package executor_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy/executor"
+	"github.com/cosmos/relayer/v2/specy/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a leaf certificate signed by the authority.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFiles writes the files, dated modTime.
+func writeFiles(t *testing.T, modTime time.Time, files map[string][]byte) {
+	t.Helper()
+	for path, bz := range files {
+		require.NoError(t, os.WriteFile(path, bz, 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+// echoEngine answers every task request with an empty result.
+type echoEngine struct {
+	types.UnimplementedRegulatorServer
+}
+
+func (echoEngine) GetTaskResult(stream types.Regulator_GetTaskResultServer) error {
+	for {
+		request, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&types.TaskResponse{Taskhash: request.Taskhash, RequestId: request.RequestId}); err != nil {
+			return err
+		}
+	}
+}
+
+// startTLSEngine serves an echo engine requiring clients certified by clientCA, and returns its address.
+func startTLSEngine(t *testing.T, serverCA, clientCA *testCA) string {
+	t.Helper()
+	certPEM, keyPEM := serverCA.issue(t, "engine.local", x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})))
+	types.RegisterRegulatorServer(server, echoEngine{})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(server.Stop)
+	return ln.Addr().String()
+}
+
+// engineRoundTrip sends a task request to the engine at addr.
+func engineRoundTrip(t *testing.T, dial executor.EngineDialer, addr string) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stream, closer, err := dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	mux := executor.NewStreamMux(zap.NewNop(), stream)
+	defer mux.Close()
+
+	response, err := mux.Do(ctx, &types.TaskRequest{Taskhash: []byte("task")})
+	if err != nil {
+		return err
+	}
+	require.Equal(t, "task", string(response.Taskhash))
+	return nil
+}
+
+func TestEngineMutualTLS(t *testing.T) {
+	serverCA, clientCA := newTestCA(t, "engine-ca"), newTestCA(t, "executor-ca")
+	addr := startTLSEngine(t, serverCA, clientCA)
+
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	certPEM, keyPEM := clientCA.issue(t, "executor", x509.ExtKeyUsageClientAuth)
+	writeFiles(t, time.Now().Add(-time.Minute), map[string][]byte{caFile: serverCA.pem, certFile: certPEM, keyFile: keyPEM})
+
+	tlsConfig, err := executor.NewEngineTLSConfig(zap.NewNop(), executor.EngineTLSConfig{
+		CAFile:     caFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ServerName: "engine.local",
+	})
+	require.NoError(t, err)
+	require.NoError(t, engineRoundTrip(t, executor.NewEngineDialer(tlsConfig, executor.DefaultEngineKeepalive), addr))
+
+	// the engine refuses executors without a client certificate
+	tlsConfig, err = executor.NewEngineTLSConfig(zap.NewNop(), executor.EngineTLSConfig{CAFile: caFile, ServerName: "engine.local"})
+	require.NoError(t, err)
+	require.Error(t, engineRoundTrip(t, executor.NewEngineDialer(tlsConfig, executor.DefaultEngineKeepalive), addr))
+
+	// the executor refuses engines certified for another name
+	tlsConfig, err = executor.NewEngineTLSConfig(zap.NewNop(), executor.EngineTLSConfig{
+		CAFile:     caFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ServerName: "other.local",
+	})
+	require.NoError(t, err)
+	require.Error(t, engineRoundTrip(t, executor.NewEngineDialer(tlsConfig, executor.DefaultEngineKeepalive), addr))
+
+	// plaintext connections to the engine fail
+	require.Error(t, engineRoundTrip(t, executor.NewEngineDialer(nil, executor.DefaultEngineKeepalive), addr))
+}
+
+func TestEngineTLSReloadsCertificates(t *testing.T) {
+	serverCA, clientCA, otherCA := newTestCA(t, "engine-ca"), newTestCA(t, "executor-ca"), newTestCA(t, "other-ca")
+	addr := startTLSEngine(t, serverCA, clientCA)
+
+	// a client certificate the engine does not trust, and the wrong engine authority
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	certPEM, keyPEM := otherCA.issue(t, "executor", x509.ExtKeyUsageClientAuth)
+	writeFiles(t, time.Now().Add(-time.Hour), map[string][]byte{caFile: otherCA.pem, certFile: certPEM, keyFile: keyPEM})
+
+	tlsConfig, err := executor.NewEngineTLSConfig(zap.NewNop(), executor.EngineTLSConfig{
+		CAFile:     caFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ServerName: "engine.local",
+	})
+	require.NoError(t, err)
+	dial := executor.NewEngineDialer(tlsConfig, executor.DefaultEngineKeepalive)
+	require.Error(t, engineRoundTrip(t, dial, addr))
+
+	// the engine authority is rotated, the client certificate is still refused
+	writeFiles(t, time.Now().Add(-time.Minute), map[string][]byte{caFile: serverCA.pem})
+	require.Error(t, engineRoundTrip(t, dial, addr))
+
+	// the client certificate is rotated
+	certPEM, keyPEM = clientCA.issue(t, "executor", x509.ExtKeyUsageClientAuth)
+	writeFiles(t, time.Now(), map[string][]byte{certFile: certPEM, keyFile: keyPEM})
+	require.NoError(t, engineRoundTrip(t, dial, addr))
+}
@@ -0,0 +1,210 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cosmos/relayer/v2/specy/types"
+	"go.uber.org/zap"
+)
+
+// ErrStreamClosed is returned for the requests in flight when the engine stream fails.
+var ErrStreamClosed = errors.New("engine stream closed")
+
+// StreamMux multiplexes concurrent task requests over a single engine stream.
+// A sender goroutine owns the sends on the stream and a receiver goroutine routes every response
+// back to the request it answers, by request id, or by task hash to the oldest request of the task
+// when the engine does not echo request ids.
+type StreamMux struct {
+	log    *zap.Logger
+	stream types.Regulator_GetTaskResultClient
+	sends  chan outgoingRequest
+
+	mu      sync.Mutex
+	pending map[string]*pendingRequest
+	// byHash holds the ids of the pending requests of each task hash, oldest first.
+	byHash map[string][]string
+
+	nextID atomic.Uint64
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+type outgoingRequest struct {
+	ctx     context.Context
+	request *types.TaskRequest
+}
+
+type pendingRequest struct {
+	id       string
+	taskHash string
+	response chan *types.TaskResponse
+}
+
+// NewStreamMux starts multiplexing the requests over the stream, until the stream fails or Close is called.
+func NewStreamMux(log *zap.Logger, stream types.Regulator_GetTaskResultClient) *StreamMux {
+	m := &StreamMux{
+		log:     log,
+		stream:  stream,
+		sends:   make(chan outgoingRequest),
+		pending: make(map[string]*pendingRequest),
+		byHash:  make(map[string][]string),
+		done:    make(chan struct{}),
+	}
+	go m.sendLoop()
+	go m.recvLoop()
+	return m
+}
+
+// Do sends the request and waits for its response, until ctx is done or the stream fails.
+// The request id of the request is set by Do.
+func (m *StreamMux) Do(ctx context.Context, request *types.TaskRequest) (*types.TaskResponse, error) {
+	p := &pendingRequest{
+		id:       strconv.FormatUint(m.nextID.Add(1), 10),
+		taskHash: string(request.Taskhash),
+		response: make(chan *types.TaskResponse, 1),
+	}
+	request.RequestId = p.id
+
+	m.mu.Lock()
+	m.pending[p.id] = p
+	m.byHash[p.taskHash] = append(m.byHash[p.taskHash], p.id)
+	m.mu.Unlock()
+	// a response arriving after the request was given up is dropped
+	defer m.remove(p)
+
+	select {
+	case m.sends <- outgoingRequest{ctx: ctx, request: request}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-m.done:
+		return nil, m.err
+	}
+
+	select {
+	case response := <-p.response:
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-m.done:
+		return nil, m.err
+	}
+}
+
+// Done is closed once the stream failed or the mux was closed.
+func (m *StreamMux) Done() <-chan struct{} {
+	return m.done
+}
+
+// Err returns the error the mux was closed with, nil while it is open.
+func (m *StreamMux) Err() error {
+	select {
+	case <-m.done:
+		return m.err
+	default:
+		return nil
+	}
+}
+
+// Close fails the pending requests and stops sending on the stream.
+func (m *StreamMux) Close() error {
+	m.close(ErrStreamClosed)
+	return m.stream.CloseSend()
+}
+
+// Pending returns the number of requests waiting for their response.
+func (m *StreamMux) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+func (m *StreamMux) close(err error) {
+	m.closeOnce.Do(func() {
+		m.err = err
+		close(m.done)
+	})
+}
+
+func (m *StreamMux) remove(p *pendingRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, p.id)
+	m.removeFromHash(p)
+}
+
+func (m *StreamMux) removeFromHash(p *pendingRequest) {
+	ids := m.byHash[p.taskHash]
+	for i, id := range ids {
+		if id == p.id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(m.byHash, p.taskHash)
+	} else {
+		m.byHash[p.taskHash] = ids
+	}
+}
+
+func (m *StreamMux) sendLoop() {
+	for {
+		select {
+		case out := <-m.sends:
+			if out.ctx.Err() != nil {
+				// given up before being sent
+				continue
+			}
+			if err := m.stream.Send(out.request); err != nil {
+				m.close(err)
+				return
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *StreamMux) recvLoop() {
+	for {
+		response, err := m.stream.Recv()
+		if err != nil {
+			m.close(err)
+			return
+		}
+		m.route(response)
+	}
+}
+
+// route hands the response to the request it answers, if still waiting.
+func (m *StreamMux) route(response *types.TaskResponse) {
+	m.mu.Lock()
+	p, ok := m.pending[response.RequestId]
+	if !ok && response.RequestId == "" {
+		if ids := m.byHash[string(response.Taskhash)]; len(ids) > 0 {
+			p, ok = m.pending[ids[0]]
+		}
+	}
+	if ok {
+		// the request stops matching responses once answered
+		delete(m.pending, p.id)
+		m.removeFromHash(p)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		m.log.Warn(
+			"Dropping specy engine response without a waiting request",
+			zap.String("task_hash", string(response.Taskhash)),
+			zap.String("request_id", response.RequestId),
+		)
+		return
+	}
+	p.response <- response
+}
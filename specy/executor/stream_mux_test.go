@@ -0,0 +1,180 @@
+package executor_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy/executor"
+	"github.com/cosmos/relayer/v2/specy/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// fakeEngineStream is the client side of an engine stream, with the requests sent by the client
+// delivered to requests and the responses written to responses delivered to the client.
+type fakeEngineStream struct {
+	grpc.ClientStream
+
+	requests  chan *types.TaskRequest
+	responses chan *types.TaskResponse
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newFakeEngineStream() *fakeEngineStream {
+	return &fakeEngineStream{
+		requests:  make(chan *types.TaskRequest, 16),
+		responses: make(chan *types.TaskResponse, 16),
+		closed:    make(chan struct{}),
+	}
+}
+
+func (s *fakeEngineStream) Send(request *types.TaskRequest) error {
+	s.requests <- request
+	return nil
+}
+
+func (s *fakeEngineStream) Recv() (*types.TaskResponse, error) {
+	select {
+	case response := <-s.responses:
+		return response, nil
+	case <-s.closed:
+		return nil, io.EOF
+	}
+}
+
+func (s *fakeEngineStream) CloseSend() error {
+	s.fail()
+	return nil
+}
+
+func (s *fakeEngineStream) fail() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+func (s *fakeEngineStream) nextRequest(t *testing.T) *types.TaskRequest {
+	t.Helper()
+	select {
+	case request := <-s.requests:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request sent on the engine stream")
+		return nil
+	}
+}
+
+type muxResult struct {
+	response *types.TaskResponse
+	err      error
+}
+
+func doAsync(ctx context.Context, mux *executor.StreamMux, taskHash string) <-chan muxResult {
+	results := make(chan muxResult, 1)
+	go func() {
+		response, err := mux.Do(ctx, &types.TaskRequest{Taskhash: []byte(taskHash)})
+		results <- muxResult{response, err}
+	}()
+	return results
+}
+
+func TestStreamMuxRoutesResponsesByRequestID(t *testing.T) {
+	stream := newFakeEngineStream()
+	mux := executor.NewStreamMux(zap.NewNop(), stream)
+	defer mux.Close()
+
+	const tasks = 10
+	results := make(map[string]<-chan muxResult, tasks)
+	for i := 0; i < tasks; i++ {
+		hash := fmt.Sprintf("task-%d", i)
+		results[hash] = doAsync(context.Background(), mux, hash)
+	}
+	requests := make([]*types.TaskRequest, tasks)
+	for i := range requests {
+		requests[i] = stream.nextRequest(t)
+		require.NotEmpty(t, requests[i].RequestId)
+	}
+
+	// the engine answers in reverse order
+	for i := len(requests) - 1; i >= 0; i-- {
+		stream.responses <- &types.TaskResponse{
+			Taskhash:  requests[i].Taskhash,
+			RequestId: requests[i].RequestId,
+			Signature: append([]byte("signed-"), requests[i].Taskhash...),
+		}
+	}
+	for hash, result := range results {
+		res := <-result
+		require.NoError(t, res.err)
+		require.Equal(t, hash, string(res.response.Taskhash))
+		require.Equal(t, "signed-"+hash, string(res.response.Signature))
+	}
+	require.Zero(t, mux.Pending())
+}
+
+func TestStreamMuxRoutesResponsesByTaskHash(t *testing.T) {
+	stream := newFakeEngineStream()
+	mux := executor.NewStreamMux(zap.NewNop(), stream)
+	defer mux.Close()
+
+	a := doAsync(context.Background(), mux, "task-a")
+	stream.nextRequest(t)
+	b := doAsync(context.Background(), mux, "task-b")
+	stream.nextRequest(t)
+
+	// an engine not echoing request ids
+	stream.responses <- &types.TaskResponse{Taskhash: []byte("task-b")}
+	stream.responses <- &types.TaskResponse{Taskhash: []byte("task-a")}
+
+	res := <-a
+	require.NoError(t, res.err)
+	require.Equal(t, "task-a", string(res.response.Taskhash))
+	res = <-b
+	require.NoError(t, res.err)
+	require.Equal(t, "task-b", string(res.response.Taskhash))
+}
+
+func TestStreamMuxDropsAbandonedRequests(t *testing.T) {
+	stream := newFakeEngineStream()
+	mux := executor.NewStreamMux(zap.NewNop(), stream)
+	defer mux.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res := <-doAsync(ctx, mux, "task-a")
+	require.ErrorIs(t, res.err, context.DeadlineExceeded)
+	require.Zero(t, mux.Pending())
+
+	// the late response of the abandoned request is dropped, the next request gets its own response
+	late := stream.nextRequest(t)
+	stream.responses <- &types.TaskResponse{Taskhash: late.Taskhash, RequestId: late.RequestId}
+
+	next := doAsync(context.Background(), mux, "task-a")
+	request := stream.nextRequest(t)
+	require.NotEqual(t, late.RequestId, request.RequestId)
+	stream.responses <- &types.TaskResponse{Taskhash: request.Taskhash, RequestId: request.RequestId, Signature: []byte("next")}
+	res = <-next
+	require.NoError(t, res.err)
+	require.Equal(t, "next", string(res.response.Signature))
+}
+
+func TestStreamMuxFailsPendingRequestsWhenStreamFails(t *testing.T) {
+	stream := newFakeEngineStream()
+	mux := executor.NewStreamMux(zap.NewNop(), stream)
+
+	result := doAsync(context.Background(), mux, "task-a")
+	stream.nextRequest(t)
+	stream.fail()
+
+	res := <-result
+	require.ErrorIs(t, res.err, io.EOF)
+	<-mux.Done()
+	require.ErrorIs(t, mux.Err(), io.EOF)
+
+	_, err := mux.Do(context.Background(), &types.TaskRequest{Taskhash: []byte("task-b")})
+	require.ErrorIs(t, err, io.EOF)
+}
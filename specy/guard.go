@@ -0,0 +1,205 @@
+package specy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Guard types.
+const (
+	// GuardTypeBalance compares the balance of Address in Denom.
+	GuardTypeBalance = "balance"
+	// GuardTypeWasmSmart compares a field of the result of a smart query against the Contract.
+	GuardTypeWasmSmart = "wasm_smart"
+	// GuardTypeHeight compares the height of the triggering block, or the latest height.
+	GuardTypeHeight = "height"
+)
+
+// Comparison operators of guards.
+const (
+	GuardOpLt  = "lt"
+	GuardOpLte = "lte"
+	GuardOpGt  = "gt"
+	GuardOpGte = "gte"
+	GuardOpEq  = "eq"
+	GuardOpNeq = "neq"
+)
+
+const (
+	rejectReasonGuardNotMet = "guard_not_met"
+	rejectReasonGuardError  = "guard_error"
+)
+
+// GuardQuerier reads the chain state that task guards are checked against.
+type GuardQuerier interface {
+	// Balance returns the amount of denom held by address.
+	Balance(ctx context.Context, address, denom string) (string, error)
+	// WasmSmartQuery runs a smart query against a wasm contract and returns its JSON result.
+	WasmSmartQuery(ctx context.Context, contract string, query []byte) ([]byte, error)
+	// LatestHeight returns the latest height of the chain.
+	LatestHeight(ctx context.Context) (int64, error)
+}
+
+// Guard is an on-chain condition checked before executing a task.
+// The task is only executed when the queried value compares to Value with Op.
+// Values are compared as decimal numbers, eq and neq also compare non numeric values as strings.
+type Guard struct {
+	Type string `json:"type"`
+
+	// Address and Denom select the balance of balance guards.
+	Address string `json:"address,omitempty"`
+	Denom   string `json:"denom,omitempty"`
+
+	// Contract and Query are the wasm contract and JSON query message of wasm_smart guards.
+	// Field is the dot separated path of the compared value in the query result, e.g. "config.paused".
+	Contract string          `json:"contract,omitempty"`
+	Query    json.RawMessage `json:"query,omitempty"`
+	Field    string          `json:"field,omitempty"`
+
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// Validate checks that the guard can be evaluated.
+func (g *Guard) Validate() error {
+	switch g.Type {
+	case GuardTypeBalance:
+		if g.Address == "" || g.Denom == "" {
+			return fmt.Errorf("balance guard requires an address and a denom")
+		}
+	case GuardTypeWasmSmart:
+		if g.Contract == "" || len(g.Query) == 0 {
+			return fmt.Errorf("wasm_smart guard requires a contract and a query")
+		}
+		if !json.Valid(g.Query) {
+			return fmt.Errorf("wasm_smart guard query is not valid JSON")
+		}
+	case GuardTypeHeight:
+		if _, err := strconv.ParseInt(g.Value, 10, 64); err != nil {
+			return fmt.Errorf("height guard value must be a height, got %q", g.Value)
+		}
+	default:
+		return fmt.Errorf("unsupported guard type %q", g.Type)
+	}
+
+	switch g.Op {
+	case GuardOpLt, GuardOpLte, GuardOpGt, GuardOpGte, GuardOpEq, GuardOpNeq:
+		return nil
+	default:
+		return fmt.Errorf("unsupported guard operator %q", g.Op)
+	}
+}
+
+// Check queries the guarded value and reports whether the guard holds.
+func (g *Guard) Check(ctx context.Context, querier GuardQuerier, trigger Trigger) (bool, error) {
+	if querier == nil {
+		return false, fmt.Errorf("no guard querier configured")
+	}
+
+	var actual string
+	switch g.Type {
+	case GuardTypeBalance:
+		amount, err := querier.Balance(ctx, g.Address, g.Denom)
+		if err != nil {
+			return false, fmt.Errorf("failed to query balance of %s: %w", g.Address, err)
+		}
+		actual = amount
+
+	case GuardTypeWasmSmart:
+		result, err := querier.WasmSmartQuery(ctx, g.Contract, g.Query)
+		if err != nil {
+			return false, fmt.Errorf("failed to query contract %s: %w", g.Contract, err)
+		}
+		actual, err = jsonField(result, g.Field)
+		if err != nil {
+			return false, err
+		}
+
+	case GuardTypeHeight:
+		height := trigger.Height
+		if height == 0 {
+			var err error
+			if height, err = querier.LatestHeight(ctx); err != nil {
+				return false, fmt.Errorf("failed to query latest height: %w", err)
+			}
+		}
+		actual = strconv.FormatInt(height, 10)
+
+	default:
+		return false, fmt.Errorf("unsupported guard type %q", g.Type)
+	}
+
+	return compareGuardValues(actual, g.Op, g.Value)
+}
+
+// jsonField returns the value at the dot separated path of a JSON document, as a string.
+func jsonField(document []byte, path string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("query result is not valid JSON: %w", err)
+	}
+
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("field %q not found in query result", path)
+			}
+			if value, ok = object[key]; !ok {
+				return "", fmt.Errorf("field %q not found in query result", path)
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		bz, err := json.Marshal(v)
+		return string(bz), err
+	}
+}
+
+// compareGuardValues compares actual to expected with the operator.
+func compareGuardValues(actual, op, expected string) (bool, error) {
+	// amounts may exceed the 64 bits of precision of a default big.Float
+	a, aok := new(big.Float).SetPrec(256).SetString(actual)
+	e, eok := new(big.Float).SetPrec(256).SetString(expected)
+	if aok && eok {
+		c := a.Cmp(e)
+		switch op {
+		case GuardOpLt:
+			return c < 0, nil
+		case GuardOpLte:
+			return c <= 0, nil
+		case GuardOpGt:
+			return c > 0, nil
+		case GuardOpGte:
+			return c >= 0, nil
+		case GuardOpEq:
+			return c == 0, nil
+		case GuardOpNeq:
+			return c != 0, nil
+		}
+	}
+
+	switch op {
+	case GuardOpEq:
+		return actual == expected, nil
+	case GuardOpNeq:
+		return actual != expected, nil
+	case GuardOpLt, GuardOpLte, GuardOpGt, GuardOpGte:
+		return false, fmt.Errorf("cannot compare non numeric values %q and %q with %s", actual, expected, op)
+	default:
+		return false, fmt.Errorf("unsupported guard operator %q", op)
+	}
+}
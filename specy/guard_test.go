@@ -0,0 +1,87 @@
+package specy_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeGuardQuerier is a specy.GuardQuerier answering from fixed chain state.
+type fakeGuardQuerier struct {
+	balances     map[string]string
+	queryResults map[string]string
+	latestHeight int64
+}
+
+func (q *fakeGuardQuerier) Balance(_ context.Context, address, denom string) (string, error) {
+	if amount, ok := q.balances[address+"/"+denom]; ok {
+		return amount, nil
+	}
+	return "0", nil
+}
+
+func (q *fakeGuardQuerier) WasmSmartQuery(_ context.Context, contract string, _ []byte) ([]byte, error) {
+	return []byte(q.queryResults[contract]), nil
+}
+
+func (q *fakeGuardQuerier) LatestHeight(context.Context) (int64, error) {
+	return q.latestHeight, nil
+}
+
+func TestGuardCheck(t *testing.T) {
+	querier := &fakeGuardQuerier{
+		balances:     map[string]string{"cosmos1pool/uatom": "1500000000000000000000"},
+		queryResults: map[string]string{"cosmos1contract": `{"config":{"paused":false,"pending":"42"}}`},
+		latestHeight: 100,
+	}
+
+	for _, tc := range []struct {
+		name    string
+		guard   specy.Guard
+		trigger specy.Trigger
+		holds   bool
+	}{
+		{"large balance below threshold", specy.Guard{Type: specy.GuardTypeBalance, Address: "cosmos1pool", Denom: "uatom", Op: specy.GuardOpLt, Value: "1500000000000000000001"}, specy.Trigger{}, true},
+		{"large balance not below itself", specy.Guard{Type: specy.GuardTypeBalance, Address: "cosmos1pool", Denom: "uatom", Op: specy.GuardOpLt, Value: "1500000000000000000000"}, specy.Trigger{}, false},
+		{"missing denom", specy.Guard{Type: specy.GuardTypeBalance, Address: "cosmos1pool", Denom: "uosmo", Op: specy.GuardOpEq, Value: "0"}, specy.Trigger{}, true},
+		{"numeric field", specy.Guard{Type: specy.GuardTypeWasmSmart, Contract: "cosmos1contract", Query: json.RawMessage(`{"config":{}}`), Field: "config.pending", Op: specy.GuardOpGt, Value: "0"}, specy.Trigger{}, true},
+		{"boolean field", specy.Guard{Type: specy.GuardTypeWasmSmart, Contract: "cosmos1contract", Query: json.RawMessage(`{"config":{}}`), Field: "config.paused", Op: specy.GuardOpEq, Value: "true"}, specy.Trigger{}, false},
+		{"triggering height", specy.Guard{Type: specy.GuardTypeHeight, Op: specy.GuardOpGte, Value: "50"}, specy.Trigger{Height: 60}, true},
+		{"latest height", specy.Guard{Type: specy.GuardTypeHeight, Op: specy.GuardOpGte, Value: "150"}, specy.Trigger{}, false},
+	} {
+		require.NoError(t, tc.guard.Validate(), tc.name)
+		holds, err := tc.guard.Check(context.Background(), querier, tc.trigger)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.holds, holds, tc.name)
+	}
+
+	missingField := specy.Guard{Type: specy.GuardTypeWasmSmart, Contract: "cosmos1contract", Query: json.RawMessage(`{}`), Field: "config.owner", Op: specy.GuardOpEq, Value: "x"}
+	_, err := missingField.Check(context.Background(), querier, specy.Trigger{})
+	require.Error(t, err)
+
+	require.Error(t, (&specy.Guard{Type: specy.GuardTypeBalance, Address: "cosmos1pool", Op: specy.GuardOpLt, Value: "1"}).Validate())
+	require.Error(t, (&specy.Guard{Type: specy.GuardTypeHeight, Op: "between", Value: "1"}).Validate())
+}
+
+func TestSchedulerSkipsTaskWhenGuardDoesNotHold(t *testing.T) {
+	recorder := new(executionRecorder)
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorder.execute)
+	scheduler.SetGuardQuerier(&fakeGuardQuerier{})
+
+	task := specy.NewTask("guarded", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+	task.Guard = &specy.Guard{Type: specy.GuardTypeHeight, Op: specy.GuardOpGte, Value: "10"}
+	require.NoError(t, scheduler.Register(task))
+
+	scheduler.TriggerBlockTasks(9, time.Time{})
+	require.Empty(t, recorder.take())
+	scheduler.TriggerBlockTasks(10, time.Time{})
+	require.Equal(t, []string{"guarded"}, recorder.take())
+
+	task.Guard = &specy.Guard{Type: "vibes", Op: specy.GuardOpEq}
+	require.Error(t, scheduler.Register(task))
+}
@@ -0,0 +1,156 @@
+package specy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultHistoryMaxRecords = 100
+	DefaultHistoryMaxAge     = 7 * 24 * time.Hour
+	// DefaultExecutionLedgerMaxAge bounds how long completed executions are remembered to deduplicate replays.
+	DefaultExecutionLedgerMaxAge = 30 * 24 * time.Hour
+	DefaultHistoryPruneInterval  = time.Hour
+)
+
+// Outcomes of a task execution recorded in the execution history.
+const (
+	ExecutionStatusSucceeded = "succeeded"
+	ExecutionStatusFailed    = "failed"
+	// ExecutionStatusSkipped executions did not run because the guard of the task did not hold or could not be checked.
+	ExecutionStatusSkipped = "skipped"
+)
+
+// ExecutionRecord is an entry of the execution history of a task.
+// The scheduler records the execution, the executor fills in the engine and transaction details it observed.
+type ExecutionRecord struct {
+	ExecutionID   string    `json:"execution_id"`
+	TaskHash      string    `json:"task_hash"`
+	TriggerHeight int64     `json:"trigger_height,omitempty"`
+	TriggerTime   time.Time `json:"trigger_time"`
+	TriggerEvent  string    `json:"trigger_event,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+
+	// EngineLatency is the time the engine took to answer the task request.
+	EngineLatency time.Duration `json:"engine_latency,omitempty"`
+	// EngineStatus and EngineError are the status and the error_info of the engine result.
+	EngineStatus bool   `json:"engine_status"`
+	EngineError  string `json:"engine_error,omitempty"`
+	// EngineFailure describes the failure of the task request when the engine could not be invoked.
+	EngineFailure *EngineFailure `json:"engine_failure,omitempty"`
+
+	// TxHash is the hash of the transaction submitting the engine result to the chain.
+	TxHash string `json:"tx_hash,omitempty"`
+	// TxConfirmed reports whether the transaction was found on chain, with its TxHeight, TxCode and GasUsed.
+	TxConfirmed bool   `json:"tx_confirmed,omitempty"`
+	TxHeight    int64  `json:"tx_height,omitempty"`
+	TxCode      uint32 `json:"tx_code"`
+	GasUsed     int64  `json:"gas_used,omitempty"`
+}
+
+// EngineFailure describes a task request the engine did not answer.
+type EngineFailure struct {
+	// Code is the grpc code of the last attempt, e.g. DeadlineExceeded or Unavailable.
+	Code string `json:"code"`
+	// Attempts is the number of times the request was sent to an engine node.
+	Attempts int `json:"attempts"`
+	// CircuitOpen reports whether the request failed fast on the open circuits of the engine nodes.
+	CircuitOpen bool `json:"circuit_open,omitempty"`
+}
+
+// SetHistoryRetention bounds the execution history kept per task to the maxRecords most recent records
+// no older than maxAge. A non positive value keeps the current bound.
+func (s *Scheduler) SetHistoryRetention(maxRecords int, maxAge time.Duration) {
+	if maxRecords > 0 {
+		s.historyMaxRecords = maxRecords
+	}
+	if maxAge > 0 {
+		s.historyMaxAge = maxAge
+	}
+}
+
+// SetExecutionLedgerRetention sets how long the completed executions are remembered to skip their replays.
+// A non positive maxAge keeps the current value.
+func (s *Scheduler) SetExecutionLedgerRetention(maxAge time.Duration) {
+	if maxAge > 0 {
+		s.ledgerMaxAge = maxAge
+	}
+}
+
+// History returns the execution history of the task, most recent first, up to limit records.
+// A non positive limit returns the whole history.
+func (s *Scheduler) History(taskHash string, limit int) ([]*ExecutionRecord, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+	return s.store.ExecutionHistory(taskHash, limit)
+}
+
+// recordExecution completes the record of an execution and appends it to the history of the task.
+// The history is pruned past the retention limits by PruneHistory.
+func (s *Scheduler) recordExecution(record *ExecutionRecord, status string, err error) {
+	record.FinishedAt = time.Now().UTC()
+	record.Status = status
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if s.store == nil {
+		return
+	}
+
+	if err := s.store.AppendExecutionRecord(record); err != nil {
+		s.log.Error(
+			"Failed to record task execution",
+			zap.String("task_hash", record.TaskHash),
+			zap.String("execution_id", record.ExecutionID),
+			zap.Error(err),
+		)
+	}
+}
+
+// PruneHistory deletes the execution records past the history retention limits, including the ones of the
+// unregistered tasks, and the completed executions older than the ledger retention.
+func (s *Scheduler) PruneHistory() error {
+	if s.store == nil {
+		return nil
+	}
+
+	now := time.Now()
+	taskHashes, err := s.store.ExecutionHistoryTasks()
+	if err != nil {
+		return fmt.Errorf("failed to list execution histories: %w", err)
+	}
+	for _, taskHash := range taskHashes {
+		if err := s.store.PruneExecutionHistory(taskHash, s.historyMaxRecords, now.Add(-s.historyMaxAge)); err != nil {
+			return fmt.Errorf("failed to prune execution history of task %s: %w", taskHash, err)
+		}
+	}
+	if err := s.store.PruneExecutionLedger(now.Add(-s.ledgerMaxAge)); err != nil {
+		return fmt.Errorf("failed to prune execution ledger: %w", err)
+	}
+	return nil
+}
+
+// RunHistoryPruning prunes the execution history every interval until ctx is done.
+func (s *Scheduler) RunHistoryPruning(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.PruneHistory(); err != nil {
+			s.log.Error("Failed to prune task execution history", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
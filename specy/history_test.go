@@ -0,0 +1,147 @@
+package specy_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExecutionHistoryRetention(t *testing.T) {
+	db := dbm.NewMemDB()
+	store := specy.NewTaskStore(db)
+	now := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.AppendExecutionRecord(&specy.ExecutionRecord{
+			ExecutionID: fmt.Sprintf("exec-%d", i),
+			TaskHash:    "a",
+			FinishedAt:  now.Add(time.Duration(i) * time.Hour),
+			Status:      specy.ExecutionStatusSucceeded,
+		}))
+		require.NoError(t, store.SetExecutionCompleted("a", fmt.Sprintf("exec-%d", i), now.Add(time.Duration(i)*time.Hour)))
+	}
+	require.NoError(t, store.AppendExecutionRecord(&specy.ExecutionRecord{ExecutionID: "other", TaskHash: "b", FinishedAt: now}))
+
+	records, err := store.ExecutionHistory("a", 2)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "exec-4", records[0].ExecutionID)
+	require.Equal(t, "exec-3", records[1].ExecutionID)
+
+	// keep at most 3 records, none finished before the second one
+	require.NoError(t, store.PruneExecutionHistory("a", 3, now.Add(90*time.Minute)))
+	records, err = store.ExecutionHistory("a", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "exec-2", records[2].ExecutionID)
+
+	// the ledger of completed executions is pruned separately, by completion time only
+	completed, err := store.ExecutionCompleted("a", "exec-0")
+	require.NoError(t, err)
+	require.True(t, completed)
+	require.NoError(t, store.SetExecutionCompleted("b", "other", now.Add(30*time.Minute)))
+	require.NoError(t, store.PruneExecutionLedger(now.Add(90*time.Minute)))
+	for id, want := range map[string]bool{"exec-0": false, "exec-1": false, "exec-2": true, "exec-4": true} {
+		completed, err = store.ExecutionCompleted("a", id)
+		require.NoError(t, err)
+		require.Equal(t, want, completed, id)
+	}
+	completed, err = store.ExecutionCompleted("b", "other")
+	require.NoError(t, err)
+	require.False(t, completed)
+
+	// other tasks are left alone
+	records, err = store.ExecutionHistory("b", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	// deleted tasks keep their history until pruned, but not their completed executions
+	require.NoError(t, store.DeleteTask("a"))
+	records, err = store.ExecutionHistory("a", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	completed, err = store.ExecutionCompleted("a", "exec-4")
+	require.NoError(t, err)
+	require.False(t, completed)
+	iter, err := dbm.IteratePrefix(db, []byte("exec_at/"))
+	require.NoError(t, err)
+	require.False(t, iter.Valid())
+	require.NoError(t, iter.Close())
+
+	taskHashes, err := store.ExecutionHistoryTasks()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, taskHashes)
+	require.NoError(t, store.PruneExecutionHistory("a", 3, now.Add(10*time.Hour)))
+	taskHashes, err = store.ExecutionHistoryTasks()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, taskHashes)
+}
+
+func TestSchedulerRecordsExecutionHistory(t *testing.T) {
+	store := specy.NewTaskStore(dbm.NewMemDB())
+	recorder := &executionRecorder{failing: map[string]bool{"failing": true}}
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), store, recorder.execute)
+	scheduler.SetGuardQuerier(&fakeGuardQuerier{})
+	scheduler.SetHistoryRetention(2, 0)
+
+	guarded := specy.NewTask("guarded", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+	guarded.Guard = &specy.Guard{Type: specy.GuardTypeHeight, Op: specy.GuardOpGte, Value: "11"}
+	for _, task := range []*specy.Task{
+		specy.NewTask("ok", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{}),
+		specy.NewTask("failing", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{}),
+		guarded,
+	} {
+		require.NoError(t, scheduler.Register(task))
+	}
+
+	blockTime := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+	scheduler.TriggerBlockTasks(10, blockTime)
+
+	history := func(taskHash string) []*specy.ExecutionRecord {
+		records, err := scheduler.History(taskHash, 0)
+		require.NoError(t, err)
+		return records
+	}
+
+	records := history("ok")
+	require.Len(t, records, 1)
+	require.Equal(t, specy.ExecutionID("ok", specy.Trigger{Height: 10, Time: blockTime}), records[0].ExecutionID)
+	require.Equal(t, int64(10), records[0].TriggerHeight)
+	require.True(t, blockTime.Equal(records[0].TriggerTime))
+	require.Equal(t, specy.ExecutionStatusSucceeded, records[0].Status)
+	require.Equal(t, "tx-ok", records[0].TxHash)
+	require.False(t, records[0].FinishedAt.Before(records[0].StartedAt))
+
+	records = history("failing")
+	require.Len(t, records, 1)
+	require.Equal(t, specy.ExecutionStatusFailed, records[0].Status)
+	require.Equal(t, "execution of failing failed", records[0].Error)
+
+	records = history("guarded")
+	require.Len(t, records, 1)
+	require.Equal(t, specy.ExecutionStatusSkipped, records[0].Status)
+	require.Empty(t, records[0].TxHash)
+
+	// the pruned history keeps the most recent executions
+	scheduler.TriggerBlockTasks(11, blockTime.Add(time.Minute))
+	scheduler.TriggerBlockTasks(12, blockTime.Add(2*time.Minute))
+	require.Len(t, history("ok"), 3)
+	require.NoError(t, scheduler.PruneHistory())
+	records = history("ok")
+	require.Len(t, records, 2)
+	require.Equal(t, int64(12), records[0].TriggerHeight)
+	require.Equal(t, int64(11), records[1].TriggerHeight)
+
+	// the history of a cancelled task is kept, and pruned like the others
+	scheduler.Unregister("failing")
+	require.Len(t, history("failing"), 2)
+	scheduler.SetHistoryRetention(1, 0)
+	require.NoError(t, scheduler.PruneHistory())
+	require.Len(t, history("failing"), 1)
+}
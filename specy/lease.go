@@ -0,0 +1,223 @@
+package specy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultLeaseTTL = 15 * time.Second
+
+	rejectReasonNotOwner = "not_owner"
+
+	leaseLockRetryDelay = 50 * time.Millisecond
+)
+
+// Ownership decides which tasks this scheduler instance executes.
+// Tasks owned by another instance are accounted for but not executed.
+type Ownership interface {
+	Owns(taskHash string) bool
+}
+
+// lease is the content of the lease file.
+type lease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LeaseElector elects a leader among scheduler replicas sharing a lease file, e.g. on shared storage.
+// The leader owns every task. It renews its lease every ttl/3, and a standby takes over once
+// the lease expired, so within ttl after the leader stopped renewing.
+// A leader that cannot renew its lease stops considering itself leader when the lease expires.
+// Replicas must keep their clocks in sync well within ttl.
+type LeaseElector struct {
+	log  *zap.Logger
+	path string
+	id   string
+	ttl  time.Duration
+	lock *flock.Flock
+
+	mu        sync.Mutex
+	expiresAt time.Time
+}
+
+var _ Ownership = (*LeaseElector)(nil)
+
+// NewLeaseElector returns an elector for the replica id, competing for the lease file at path.
+func NewLeaseElector(log *zap.Logger, path, id string, ttl time.Duration) *LeaseElector {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	return &LeaseElector{
+		log:  log,
+		path: path,
+		id:   id,
+		ttl:  ttl,
+		lock: flock.New(path + ".lock"),
+	}
+}
+
+// Run competes for the lease until ctx is done, then releases it if held.
+func (e *LeaseElector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Heartbeat(ctx); err != nil && ctx.Err() == nil {
+			e.log.Warn("Failed to renew scheduler lease", zap.String("lease", e.path), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := e.Release(); err != nil {
+				e.log.Warn("Failed to release scheduler lease", zap.String("lease", e.path), zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsLeader reports whether this replica holds an unexpired lease.
+func (e *LeaseElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Now().Before(e.expiresAt)
+}
+
+// Owns reports whether this replica executes the task, which is the case of every task on the leader.
+func (e *LeaseElector) Owns(string) bool {
+	return e.IsLeader()
+}
+
+// Heartbeat acquires the lease if it is free or expired, or renews it if held by this replica.
+func (e *LeaseElector) Heartbeat(ctx context.Context) error {
+	wasLeader := e.IsLeader()
+
+	var acquired bool
+	var expiresAt time.Time
+	err := e.withLock(ctx, func() error {
+		current, err := e.read()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if current.Holder != e.id && now.Before(current.ExpiresAt) {
+			return nil
+		}
+		expiresAt = now.Add(e.ttl)
+		acquired = true
+		return e.write(lease{Holder: e.id, ExpiresAt: expiresAt})
+	})
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	if acquired {
+		e.expiresAt = expiresAt
+	} else {
+		e.expiresAt = time.Time{}
+	}
+	e.mu.Unlock()
+
+	if acquired && !wasLeader {
+		e.log.Info("Acquired scheduler lease", zap.String("lease", e.path), zap.String("replica", e.id))
+	} else if !acquired && wasLeader {
+		e.log.Warn("Lost scheduler lease", zap.String("lease", e.path), zap.String("replica", e.id))
+	}
+	return nil
+}
+
+// Release gives up the lease if held by this replica, so that a standby can take over right away.
+func (e *LeaseElector) Release() error {
+	e.mu.Lock()
+	e.expiresAt = time.Time{}
+	e.mu.Unlock()
+
+	// the context of Run is done at this point, give the release a bounded time of its own
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+	defer cancel()
+	return e.withLock(ctx, func() error {
+		current, err := e.read()
+		if err != nil || current.Holder != e.id {
+			return err
+		}
+		return e.write(lease{Holder: e.id})
+	})
+}
+
+// withLock runs f while holding the lock file guarding the lease file.
+func (e *LeaseElector) withLock(ctx context.Context, f func() error) error {
+	locked, err := e.lock.TryLockContext(ctx, leaseLockRetryDelay)
+	if err != nil {
+		return fmt.Errorf("failed to lock lease: %w", err)
+	}
+	if !locked {
+		return fmt.Errorf("failed to lock lease %s", e.path)
+	}
+	defer func() {
+		if err := e.lock.Unlock(); err != nil {
+			e.log.Error("Failed to unlock lease", zap.String("lease", e.path), zap.Error(err))
+		}
+	}()
+	return f()
+}
+
+func (e *LeaseElector) read() (lease, error) {
+	var l lease
+	bz, err := os.ReadFile(e.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	if len(bz) == 0 {
+		return l, nil
+	}
+	if err := json.Unmarshal(bz, &l); err != nil {
+		return l, fmt.Errorf("failed to decode lease %s: %w", e.path, err)
+	}
+	return l, nil
+}
+
+// write replaces the lease file atomically.
+func (e *LeaseElector) write(l lease) error {
+	bz, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(e.path, bz)
+}
+
+// writeFileAtomic replaces the file at path with bz, so that readers never see a partial write.
+func writeFileAtomic(path string, bz []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bz); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
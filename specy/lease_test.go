@@ -0,0 +1,150 @@
+package specy_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func leaders(electors []*specy.LeaseElector) []int {
+	var indexes []int
+	for i, elector := range electors {
+		if elector.IsLeader() {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func TestLeaseElectorFailover(t *testing.T) {
+	const ttl = 300 * time.Millisecond
+	path := filepath.Join(t.TempDir(), "scheduler.lease")
+
+	electors := make([]*specy.LeaseElector, 3)
+	cancels := make([]context.CancelFunc, 3)
+	var wg sync.WaitGroup
+	// stop the electors before the lease directory is removed
+	defer wg.Wait()
+	for i := range electors {
+		electors[i] = specy.NewLeaseElector(zap.NewNop(), path, fmt.Sprintf("replica-%d", i), ttl)
+		var ctx context.Context
+		ctx, cancels[i] = context.WithCancel(context.Background())
+		defer cancels[i]()
+		wg.Add(1)
+		go func(elector *specy.LeaseElector) {
+			defer wg.Done()
+			elector.Run(ctx)
+		}(electors[i])
+	}
+
+	// exactly one replica leads
+	require.Eventually(t, func() bool { return len(leaders(electors)) == 1 }, time.Second, 10*time.Millisecond)
+	for i := 0; i < 10; i++ {
+		require.Len(t, leaders(electors), 1)
+		time.Sleep(ttl / 10)
+	}
+	first := leaders(electors)[0]
+
+	// a leader shutting down releases the lease and a standby takes over
+	cancels[first]()
+	require.Eventually(t, func() bool {
+		l := leaders(electors)
+		return len(l) == 1 && l[0] != first
+	}, ttl, 10*time.Millisecond)
+	second := leaders(electors)[0]
+	require.False(t, electors[first].Owns("task"))
+	require.True(t, electors[second].Owns("task"))
+}
+
+func TestLeaseElectorTakeoverAfterExpiry(t *testing.T) {
+	const ttl = 200 * time.Millisecond
+	path := filepath.Join(t.TempDir(), "scheduler.lease")
+	ctx := context.Background()
+
+	hung := specy.NewLeaseElector(zap.NewNop(), path, "hung", ttl)
+	standby := specy.NewLeaseElector(zap.NewNop(), path, "standby", ttl)
+
+	require.NoError(t, hung.Heartbeat(ctx))
+	require.NoError(t, standby.Heartbeat(ctx))
+	require.True(t, hung.IsLeader())
+	require.False(t, standby.IsLeader())
+
+	// the leader stops heartbeating, its lease lapses and the standby takes over
+	time.Sleep(ttl)
+	require.False(t, hung.IsLeader())
+	require.NoError(t, standby.Heartbeat(ctx))
+	require.True(t, standby.IsLeader())
+
+	// the former leader does not get the lease back while it is held
+	require.NoError(t, hung.Heartbeat(ctx))
+	require.False(t, hung.IsLeader())
+}
+
+func TestSchedulerExecutesOwnedTasksOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.lease")
+	task := specy.NewTask("a", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+
+	recorders := make([]*executionRecorder, 2)
+	schedulers := make([]*specy.Scheduler, 2)
+	for i := range schedulers {
+		elector := specy.NewLeaseElector(zap.NewNop(), path, fmt.Sprintf("replica-%d", i), time.Minute)
+		require.NoError(t, elector.Heartbeat(context.Background()))
+
+		recorders[i] = new(executionRecorder)
+		schedulers[i] = specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorders[i].execute)
+		schedulers[i].SetOwnership(elector)
+		require.NoError(t, schedulers[i].Register(task))
+	}
+
+	for _, scheduler := range schedulers {
+		scheduler.TriggerBlockTasks(10, time.Time{})
+	}
+	require.Equal(t, []string{"a"}, recorders[0].take())
+	require.Empty(t, recorders[1].take())
+}
+
+func TestStandbyDoesNotAccountForMissedActivations(t *testing.T) {
+	const day = 24 * 60 * 60
+	path := filepath.Join(t.TempDir(), "scheduler.lease")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leader := specy.NewLeaseElector(zap.NewNop(), path, "leader", time.Minute)
+	standby := specy.NewLeaseElector(zap.NewNop(), path, "standby", time.Minute)
+	require.NoError(t, leader.Heartbeat(ctx))
+	require.NoError(t, standby.Heartbeat(ctx))
+
+	// a daily task that last ran 3 days and a half ago on both replicas
+	startTime := time.Now().Add(-84 * time.Hour).Truncate(time.Second)
+	task := specy.NewTask("daily", "rewards", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeTimeInterval, day, startTime)
+
+	stores := make([]*specy.TaskStore, 2)
+	recorders := make([]*executionRecorder, 2)
+	for i, elector := range []*specy.LeaseElector{leader, standby} {
+		stores[i] = specy.NewTaskStore(dbm.NewMemDB())
+		require.NoError(t, stores[i].SetLastExecution("daily", startTime))
+		recorders[i] = new(executionRecorder)
+		scheduler := specy.NewScheduler(ctx, zap.NewNop(), stores[i], recorders[i].execute)
+		scheduler.SetOwnership(elector)
+		require.NoError(t, scheduler.Register(task))
+	}
+
+	// the leader catches up the missed activations
+	require.Eventually(t, func() bool { return len(recorders[0].take()) == 1 }, time.Second, 10*time.Millisecond)
+
+	// the standby neither runs them nor accounts for them as run
+	time.Sleep(50 * time.Millisecond)
+	require.Empty(t, recorders[1].take())
+	last, ok, err := stores[1].LastExecution("daily")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, startTime.Equal(last))
+}
@@ -0,0 +1,104 @@
+package specy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics exposes the state of the scheduler to prometheus.
+// A nil *Metrics is valid and discards every observation.
+type Metrics struct {
+	QueueDepth         prometheus.Gauge
+	QueueCapacity      prometheus.Gauge
+	BusyWorkers        prometheus.Gauge
+	ExecutionsStarted  prometheus.Counter
+	ExecutionsRejected *prometheus.CounterVec
+	OwnedTasks         prometheus.Gauge
+	EngineNodeState    *prometheus.GaugeVec
+}
+
+// NewMetrics registers the scheduler metrics on the given registry.
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	registerer := promauto.With(registry)
+	return &Metrics{
+		QueueDepth: registerer.NewGauge(prometheus.GaugeOpts{
+			Name: "specy_scheduler_queue_depth",
+			Help: "The number of task executions waiting for a worker",
+		}),
+		QueueCapacity: registerer.NewGauge(prometheus.GaugeOpts{
+			Name: "specy_scheduler_queue_capacity",
+			Help: "The maximum number of task executions waiting for a worker",
+		}),
+		BusyWorkers: registerer.NewGauge(prometheus.GaugeOpts{
+			Name: "specy_scheduler_busy_workers",
+			Help: "The number of workers currently executing a task",
+		}),
+		ExecutionsStarted: registerer.NewCounter(prometheus.CounterOpts{
+			Name: "specy_scheduler_executions_started",
+			Help: "The total number of task executions started",
+		}),
+		ExecutionsRejected: registerer.NewCounterVec(prometheus.CounterOpts{
+			Name: "specy_scheduler_executions_rejected",
+			Help: "The total number of task executions rejected before running",
+		}, []string{"reason"}),
+		OwnedTasks: registerer.NewGauge(prometheus.GaugeOpts{
+			Name: "specy_scheduler_owned_tasks",
+			Help: "The number of registered tasks executed by this instance",
+		}),
+		EngineNodeState: registerer.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "specy_engine_node_state",
+			Help: "The state of the connection to an engine node, set to 1 for the current state",
+		}, []string{"address", "state"}),
+	}
+}
+
+func (m *Metrics) SetQueueDepth(depth int) {
+	if m == nil {
+		return
+	}
+	m.QueueDepth.Set(float64(depth))
+}
+
+func (m *Metrics) SetQueueCapacity(capacity int) {
+	if m == nil {
+		return
+	}
+	m.QueueCapacity.Set(float64(capacity))
+}
+
+func (m *Metrics) AddBusyWorkers(delta int) {
+	if m == nil {
+		return
+	}
+	m.BusyWorkers.Add(float64(delta))
+}
+
+func (m *Metrics) IncExecutionsStarted() {
+	if m == nil {
+		return
+	}
+	m.ExecutionsStarted.Inc()
+}
+
+func (m *Metrics) IncExecutionsRejected(reason string) {
+	if m == nil {
+		return
+	}
+	m.ExecutionsRejected.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) SetOwnedTasks(count int) {
+	if m == nil {
+		return
+	}
+	m.OwnedTasks.Set(float64(count))
+}
+
+// SetEngineNodeState reports the current state of the connection to the engine node at address.
+func (m *Metrics) SetEngineNodeState(address, state string) {
+	if m == nil {
+		return
+	}
+	m.EngineNodeState.DeletePartialMatch(prometheus.Labels{"address": address})
+	m.EngineNodeState.WithLabelValues(address, state).Set(1)
+}
@@ -0,0 +1,49 @@
+package specy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Misfire policies decide what happens to the activations of a time based task
+// that were missed while the scheduler was not running.
+const (
+	// MisfirePolicySkip drops every missed activation.
+	MisfirePolicySkip = "skip"
+	// MisfirePolicyRunOnce runs a single execution for all the missed activations.
+	MisfirePolicyRunOnce = "run_once"
+	// MisfirePolicyRunAllMissed runs one execution per missed activation, up to the max missed runs.
+	MisfirePolicyRunAllMissed = "run_all_missed"
+
+	DefaultMisfirePolicy = MisfirePolicyRunOnce
+	DefaultMaxMissedRuns = 10
+)
+
+// ValidateMisfirePolicy checks that policy is empty or a known misfire policy.
+func ValidateMisfirePolicy(policy string) error {
+	switch policy {
+	case "", MisfirePolicySkip, MisfirePolicyRunOnce, MisfirePolicyRunAllMissed:
+		return nil
+	default:
+		return fmt.Errorf("unsupported misfire policy %q", policy)
+	}
+}
+
+// missedActivations returns the activations of schedule after last and up to now,
+// keeping only the most recent max of them, along with the total number of missed activations.
+func missedActivations(schedule cron.Schedule, last, now time.Time, max int) ([]time.Time, int) {
+	var (
+		slots  []time.Time
+		missed int
+	)
+	for next := schedule.Next(last); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		missed++
+		slots = append(slots, next)
+		if len(slots) > max {
+			slots = slots[1:]
+		}
+	}
+	return slots, missed
+}
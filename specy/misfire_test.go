@@ -0,0 +1,106 @@
+package specy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMisfirePolicyAfterDowntime(t *testing.T) {
+	const day = 24 * 60 * 60
+
+	// a daily task that last ran 3 days and a half ago, missing 3 activations since
+	startTime := time.Now().Add(-84 * time.Hour).Truncate(time.Second)
+
+	for _, tc := range []struct {
+		policy        string
+		maxMissedRuns int
+		runs          int
+	}{
+		{specy.MisfirePolicySkip, 0, 0},
+		{specy.MisfirePolicyRunOnce, 0, 1},
+		{specy.MisfirePolicyRunAllMissed, 0, 3},
+		{specy.MisfirePolicyRunAllMissed, 2, 2},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		store := specy.NewTaskStore(dbm.NewMemDB())
+		require.NoError(t, store.SetLastExecution("daily", startTime))
+
+		recorder := new(executionRecorder)
+		scheduler := specy.NewScheduler(ctx, zap.NewNop(), store, recorder.execute)
+
+		task := specy.NewTask("daily", "rewards", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeTimeInterval, day, startTime)
+		task.Condition.MisfirePolicy = tc.policy
+		task.Condition.MaxMissedRuns = tc.maxMissedRuns
+		require.NoError(t, scheduler.Register(task))
+
+		// the last missed activation is accounted for whatever the policy
+		latest := startTime.Add(72 * time.Hour)
+		require.Eventually(t, func() bool {
+			last, ok, err := store.LastExecution("daily")
+			return err == nil && ok && last.Equal(latest)
+		}, time.Second, 10*time.Millisecond, tc.policy)
+		require.Len(t, recorder.take(), tc.runs, tc.policy)
+
+		// restarting does not run the accounted activations again
+		restarted := specy.NewScheduler(ctx, zap.NewNop(), store, recorder.execute)
+		require.NoError(t, restarted.Load())
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, recorder.take(), tc.policy)
+
+		cancel()
+	}
+}
+
+func TestSetMisfirePolicy(t *testing.T) {
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, nil)
+	require.NoError(t, scheduler.SetMisfirePolicy(specy.MisfirePolicySkip, 5))
+	require.NoError(t, scheduler.SetMisfirePolicy("", 0))
+	require.Error(t, scheduler.SetMisfirePolicy("sometimes", 0))
+}
+
+func TestMisfirePolicyAfterDowntimeOnChainClock(t *testing.T) {
+	const day = 24 * 60 * 60
+
+	// a daily task that last ran 3 days and a half before the first block seen, missing 3 activations since
+	startTime := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	blockTime := startTime.Add(84 * time.Hour)
+
+	for _, tc := range []struct {
+		policy string
+		runs   int
+	}{
+		{specy.MisfirePolicySkip, 0},
+		{specy.MisfirePolicyRunOnce, 1},
+		{specy.MisfirePolicyRunAllMissed, 3},
+	} {
+		store := specy.NewTaskStore(dbm.NewMemDB())
+		require.NoError(t, store.SetLastExecution("daily", startTime))
+
+		recorder := new(executionRecorder)
+		scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), store, recorder.execute)
+		require.NoError(t, scheduler.SetClockMode(specy.ClockModeChain))
+
+		task := specy.NewTask("daily", "rewards", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeTimeInterval, day, startTime)
+		task.Condition.MisfirePolicy = tc.policy
+		require.NoError(t, scheduler.Register(task))
+
+		// the missed activations are handled as on the local clock
+		scheduler.TriggerBlockTasks(100, blockTime)
+		require.Len(t, recorder.take(), tc.runs, tc.policy)
+		last, ok, err := store.LastExecution("daily")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, startTime.Add(72*time.Hour), last.UTC(), tc.policy)
+
+		// the next activation is on time and runs whatever the policy
+		scheduler.TriggerBlockTasks(101, blockTime.Add(12*time.Hour))
+		require.Equal(t, []specy.Trigger{{Height: 101, Time: startTime.Add(96 * time.Hour)}}, recorder.takeTriggers(), tc.policy)
+	}
+}
@@ -0,0 +1,140 @@
+package specy
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultExecutorWorkers   = 10
+	DefaultExecutorQueueSize = 1000
+
+	rejectReasonQueueFull = "queue_full"
+	rejectReasonInFlight  = "in_flight"
+	rejectReasonDrained   = "drained"
+)
+
+// job is a single task execution waiting for a worker.
+type job struct {
+	task *Task
+	run  func(ctx context.Context)
+}
+
+// WorkerPool runs task executions on a fixed number of workers fed by a bounded queue.
+// Submitting never blocks: executions are rejected when the queue is full,
+// so that slow engine calls cannot stall block ingestion.
+type WorkerPool struct {
+	log     *zap.Logger
+	metrics *Metrics
+	queue   chan job
+
+	mu sync.Mutex
+	// inFlight counts the queued and running executions of each task.
+	inFlight map[string]int
+}
+
+// NewWorkerPool starts workers goroutines consuming a queue of queueSize executions.
+// The workers stop when ctx is done.
+func NewWorkerPool(ctx context.Context, log *zap.Logger, workers, queueSize int, metrics *Metrics) *WorkerPool {
+	if workers <= 0 {
+		workers = DefaultExecutorWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultExecutorQueueSize
+	}
+
+	p := &WorkerPool{
+		log:      log,
+		metrics:  metrics,
+		queue:    make(chan job, queueSize),
+		inFlight: make(map[string]int),
+	}
+	metrics.SetQueueCapacity(queueSize)
+
+	for i := 0; i < workers; i++ {
+		go p.work(ctx)
+	}
+	return p
+}
+
+// Submit queues an execution of the task.
+// It returns false if the execution was rejected, either because the queue is full
+// or because the task skips overlapping runs and a previous run is still in flight.
+func (p *WorkerPool) Submit(task *Task, run func(ctx context.Context)) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if task.SkipIfRunning && p.inFlight[task.TaskHash] > 0 {
+		p.log.Info("Skipping task, previous run still in flight", zap.String("task_hash", task.TaskHash))
+		p.metrics.IncExecutionsRejected(rejectReasonInFlight)
+		return false
+	}
+
+	select {
+	case p.queue <- job{task: task, run: run}:
+		p.inFlight[task.TaskHash]++
+		p.metrics.SetQueueDepth(len(p.queue))
+		return true
+	default:
+		p.log.Warn("Task execution queue is full, dropping execution", zap.String("task_hash", task.TaskHash))
+		p.metrics.IncExecutionsRejected(rejectReasonQueueFull)
+		return false
+	}
+}
+
+// InFlight returns the number of queued and running executions of the task.
+func (p *WorkerPool) InFlight(taskHash string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inFlight[taskHash]
+}
+
+// Drain drops the queued executions that have not been picked up by a worker yet,
+// and returns the number of dropped executions. Running executions are not interrupted.
+func (p *WorkerPool) Drain() int {
+	dropped := 0
+	for {
+		select {
+		case j := <-p.queue:
+			p.done(j.task.TaskHash)
+			p.metrics.IncExecutionsRejected(rejectReasonDrained)
+			dropped++
+		default:
+			p.metrics.SetQueueDepth(len(p.queue))
+			if dropped > 0 {
+				p.log.Warn("Drained task execution queue", zap.Int("dropped", dropped))
+			}
+			return dropped
+		}
+	}
+}
+
+func (p *WorkerPool) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-p.queue:
+			p.metrics.SetQueueDepth(len(p.queue))
+			p.metrics.AddBusyWorkers(1)
+			p.metrics.IncExecutionsStarted()
+
+			j.run(ctx)
+
+			p.metrics.AddBusyWorkers(-1)
+			p.done(j.task.TaskHash)
+		}
+	}
+}
+
+func (p *WorkerPool) done(taskHash string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight[taskHash] <= 1 {
+		delete(p.inFlight, taskHash)
+		return
+	}
+	p.inFlight[taskHash]--
+}
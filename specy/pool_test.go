@@ -0,0 +1,90 @@
+package specy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWorkerPoolRejectsWhenQueueFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := specy.NewWorkerPool(ctx, zap.NewNop(), 1, 1, nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func(context.Context) {
+		started <- struct{}{}
+		<-release
+	}
+
+	task := specy.NewTask("a", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+
+	// the single worker picks up the first execution, the second one fills the queue
+	require.True(t, pool.Submit(task, blocking))
+	<-started
+	require.True(t, pool.Submit(task, blocking))
+	require.False(t, pool.Submit(task, blocking))
+	require.Equal(t, 2, pool.InFlight("a"))
+
+	close(release)
+	<-started
+	require.Eventually(t, func() bool { return pool.InFlight("a") == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestWorkerPoolSkipIfRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := specy.NewWorkerPool(ctx, zap.NewNop(), 2, 10, nil)
+
+	release := make(chan struct{})
+	blocking := func(context.Context) { <-release }
+
+	skipping := specy.NewTask("skipping", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+	skipping.SkipIfRunning = true
+	overlapping := specy.NewTask("overlapping", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+
+	require.True(t, pool.Submit(skipping, blocking))
+	require.False(t, pool.Submit(skipping, blocking))
+	require.True(t, pool.Submit(overlapping, blocking))
+	require.True(t, pool.Submit(overlapping, blocking))
+
+	close(release)
+	require.Eventually(t, func() bool { return pool.InFlight("skipping") == 0 }, time.Second, 10*time.Millisecond)
+	require.True(t, pool.Submit(skipping, blocking))
+}
+
+func TestWorkerPoolDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := specy.NewWorkerPool(ctx, zap.NewNop(), 1, 10, nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	task := specy.NewTask("a", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+	require.True(t, pool.Submit(task, func(context.Context) {
+		close(started)
+		<-release
+	}))
+	<-started
+
+	ran := make(chan struct{}, 3)
+	for i := 0; i < 3; i++ {
+		require.True(t, pool.Submit(task, func(context.Context) { ran <- struct{}{} }))
+	}
+	require.Equal(t, 4, pool.InFlight("a"))
+
+	// the queued executions are dropped, the running one completes
+	require.Equal(t, 3, pool.Drain())
+	require.Equal(t, 1, pool.InFlight("a"))
+	close(release)
+	require.Eventually(t, func() bool { return pool.InFlight("a") == 0 }, time.Second, 10*time.Millisecond)
+	require.Empty(t, ran)
+}
@@ -0,0 +1,407 @@
+package specy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Scheduling clauses of a rule file.
+const (
+	ClauseAfter = "after"
+	ClauseEvery = "every"
+	ClauseUntil = "until"
+	ClauseAt    = "at"
+	ClauseOn    = "on"
+)
+
+// ParseError describes a rule file scheduling clause that could not be parsed.
+type ParseError struct {
+	// Clause is the keyword of the clause, e.g. "after".
+	Clause string
+	// Offset is the byte offset of the clause in the rule file.
+	Offset int
+	// Text is the clause text that failed to parse.
+	Text string
+	// Reason explains why the clause is invalid.
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid %q clause %q at offset %d: %s", e.Clause, e.Text, e.Offset, e.Reason)
+}
+
+// RuleSchedule is the schedule described by the scheduling clauses of a rule file:
+//
+//	after <datetime> [zone]   first activation, e.g. after 2023-01-01T00:00:00+08:00
+//	every <duration>          interval between activations, e.g. every 6 hours, every day, every 90m
+//	until <datetime> [zone]   no activation after this time
+//	at <hh:mm[:ss]> [zone]    time of day of the activations, daily unless combined with on
+//	on <weekdays> | on day N  days of the week, e.g. on mon,fri, or day of the month of the activations
+//
+// Datetimes are RFC 3339, with a Z or numeric offset, or a local datetime or date followed by
+// Z, UTC or an IANA zone name such as Asia/Shanghai. Without a zone, UTC is assumed.
+//
+// Clauses may appear anywhere in the rule file, the rest of the text is ignored.
+// A keyword only starts a clause when it is followed by something shaped like its argument: a date,
+// a time of day with a colon, a duration with a known unit or weekdays. So "count on interchainnft",
+// "sum transfers every 100 blocks" and "reward at 5 validators" are read as rule text, while
+// "after 2023-13-01" or "at 25:00" are errors.
+type RuleSchedule struct {
+	After time.Time
+	Every time.Duration
+	Until time.Time
+
+	// HasAt is set when the rule file has an at clause, at the time of day At in AtLocation.
+	HasAt      bool
+	At         time.Duration
+	AtLocation *time.Location
+
+	// Weekdays and MonthDay are set by an on clause.
+	Weekdays []time.Weekday
+	MonthDay int
+}
+
+// HasCalendar reports whether the schedule activates on a calendar, through an at or on clause.
+func (r *RuleSchedule) HasCalendar() bool {
+	return r.HasAt || len(r.Weekdays) > 0 || r.MonthDay > 0
+}
+
+// CronExpression returns the cron expression of a calendar schedule.
+func (r *RuleSchedule) CronExpression() string {
+	seconds := int(r.At / time.Second)
+	dom, dow := "*", "*"
+	if r.MonthDay > 0 {
+		dom = strconv.Itoa(r.MonthDay)
+	}
+	if len(r.Weekdays) > 0 {
+		days := make([]string, len(r.Weekdays))
+		for i, day := range r.Weekdays {
+			days[i] = strconv.Itoa(int(day))
+		}
+		dow = strings.Join(days, ",")
+	}
+
+	zone := "UTC"
+	if r.AtLocation != nil {
+		zone = r.AtLocation.String()
+	}
+	return fmt.Sprintf("CRON_TZ=%s %d %d %d %s * %s", zone, seconds%60, seconds/60%60, seconds/3600, dom, dow)
+}
+
+// ApplyTo sets the schedule of a time based condition from the rule file clauses.
+// An interval or cron expression set explicitly on the condition takes precedence.
+func (r *RuleSchedule) ApplyTo(c *Condition) {
+	if !r.Until.IsZero() {
+		c.EndTime = r.Until
+	}
+	if !r.After.IsZero() {
+		c.StartTime = r.After
+	}
+
+	switch c.IntervalType {
+	case IntervalTypeTimeInterval, "":
+		if r.HasCalendar() && c.Interval == 0 {
+			c.IntervalType = IntervalTypeCron
+			c.CronExpression = r.CronExpression()
+			return
+		}
+		if c.Interval == 0 && r.Every > 0 {
+			c.Interval = int(r.Every / time.Second)
+		}
+		if c.Interval == 0 && !r.After.IsZero() {
+			// 执行间隔默认是24小时
+			c.Interval = 24 * 60 * 60
+		}
+	case IntervalTypeCron:
+		if r.HasCalendar() && c.CronExpression == "" {
+			c.CronExpression = r.CronExpression()
+		}
+	}
+}
+
+// ruleToken is a whitespace separated word of a rule file.
+type ruleToken struct {
+	text   string
+	offset int
+}
+
+// ParseRuleSchedule parses the scheduling clauses of a rule file.
+// It returns a *ParseError for a clause that is malformed or conflicts with another clause.
+func ParseRuleSchedule(ruleFile string) (*RuleSchedule, error) {
+	tokens := tokenizeRuleFile(ruleFile)
+	r := &RuleSchedule{}
+	seen := make(map[string]bool)
+
+	for i := 0; i < len(tokens); i++ {
+		clause := strings.ToLower(tokens[i].text)
+		if i+1 == len(tokens) || !isClauseArgument(clause, tokens[i+1:]) {
+			continue
+		}
+		if seen[clause] {
+			return nil, newParseError(clause, tokens[i:i+2], "duplicate clause")
+		}
+		seen[clause] = true
+
+		var (
+			consumed int
+			err      error
+		)
+		args := tokens[i+1:]
+		switch clause {
+		case ClauseAfter:
+			r.After, consumed, err = parseRuleDatetime(args)
+		case ClauseUntil:
+			r.Until, consumed, err = parseRuleDatetime(args)
+		case ClauseEvery:
+			r.Every, consumed, err = parseRuleDuration(args)
+		case ClauseAt:
+			r.HasAt = true
+			r.At, r.AtLocation, consumed, err = parseRuleTimeOfDay(args)
+		case ClauseOn:
+			r.Weekdays, r.MonthDay, consumed, err = parseRuleDays(args)
+		}
+		if err != nil {
+			end := i + 1 + consumed
+			if consumed == 0 {
+				end = i + 2
+			}
+			return nil, newParseError(clause, tokens[i:end], err.Error())
+		}
+		i += consumed
+	}
+
+	switch {
+	case !r.After.IsZero() && !r.Until.IsZero() && !r.Until.After(r.After):
+		return nil, &ParseError{Clause: ClauseUntil, Text: r.Until.Format(time.RFC3339), Reason: "until is not after the start time"}
+	case r.Every > 0 && r.HasCalendar():
+		return nil, &ParseError{Clause: ClauseEvery, Text: r.Every.String(), Reason: "every cannot be combined with at or on"}
+	}
+	return r, nil
+}
+
+func newParseError(clause string, tokens []ruleToken, reason string) *ParseError {
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.text
+	}
+	return &ParseError{
+		Clause: clause,
+		Offset: tokens[0].offset,
+		Text:   strings.Join(words, " "),
+		Reason: reason,
+	}
+}
+
+// tokenizeRuleFile splits the rule file into words, dropping the punctuation that may trail a clause.
+func tokenizeRuleFile(ruleFile string) []ruleToken {
+	var tokens []ruleToken
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if text := strings.TrimRight(ruleFile[start:end], ",;"); text != "" {
+			tokens = append(tokens, ruleToken{text: text, offset: start})
+		}
+		start = -1
+	}
+	for i, r := range ruleFile {
+		if unicode.IsSpace(r) {
+			flush(i)
+		} else if start < 0 {
+			start = i
+		}
+	}
+	flush(len(ruleFile))
+	return tokens
+}
+
+// isClauseArgument reports whether the words following the clause keyword are shaped like its argument.
+func isClauseArgument(clause string, args []ruleToken) bool {
+	word := strings.ToLower(args[0].text)
+	switch clause {
+	case ClauseAfter, ClauseUntil:
+		// a date, possibly followed by a time: 2006-01-02...
+		return len(word) >= 10 && isDigits(word[:4]) && word[4] == '-' && isDigits(word[5:7]) && word[7] == '-'
+	case ClauseAt:
+		// a time of day: 15:04...
+		return word != "" && isDigits(word[:1]) && strings.Contains(word, ":")
+	case ClauseEvery:
+		if _, isUnit := durationUnits[word]; isUnit {
+			return true
+		}
+		if isDigits(word) {
+			// a number of units: 6 hours
+			if len(args) < 2 {
+				return false
+			}
+			_, isUnit := durationUnits[strings.ToLower(args[1].text)]
+			return isUnit
+		}
+		// a duration: 90m
+		_, err := time.ParseDuration(word)
+		return err == nil
+	case ClauseOn:
+		if word == "day" {
+			return true
+		}
+		_, isWeekday := weekdays[strings.SplitN(word, ",", 2)[0]]
+		return isWeekday
+	default:
+		return false
+	}
+}
+
+func isDigits(word string) bool {
+	for _, r := range word {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return word != ""
+}
+
+var durationUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// parseRuleZone parses a zone name: Z, UTC or an IANA zone name.
+// The boolean is false if word is not a zone name.
+func parseRuleZone(word string) (*time.Location, bool, error) {
+	switch {
+	case word == "Z" || word == "UTC":
+		return time.UTC, true, nil
+	case strings.Contains(word, "/"):
+		loc, err := time.LoadLocation(word)
+		if err != nil {
+			return nil, true, fmt.Errorf("unknown time zone %q", word)
+		}
+		return loc, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// parseRuleDatetime parses a datetime and its optional zone, returning the number of words consumed.
+func parseRuleDatetime(args []ruleToken) (time.Time, int, error) {
+	word := args[0].text
+	if t, err := time.Parse(time.RFC3339, word); err == nil {
+		return t, 1, nil
+	}
+
+	loc, consumed := time.UTC, 1
+	if len(args) > 1 {
+		zone, ok, err := parseRuleZone(args[1].text)
+		if err != nil {
+			return time.Time{}, 2, err
+		}
+		if ok {
+			loc, consumed = zone, 2
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, word, loc); err == nil {
+			return t, consumed, nil
+		}
+	}
+	return time.Time{}, 1, fmt.Errorf("expected an RFC 3339 datetime or a date, got %q", word)
+}
+
+// parseRuleDuration parses an interval such as "6 hours", "day" or "90m".
+func parseRuleDuration(args []ruleToken) (time.Duration, int, error) {
+	word := strings.ToLower(args[0].text)
+	if unit, ok := durationUnits[word]; ok {
+		return unit, 1, nil
+	}
+
+	if n, err := strconv.Atoi(word); err == nil {
+		if len(args) < 2 {
+			return 0, 1, fmt.Errorf("missing unit after %d", n)
+		}
+		unit, ok := durationUnits[strings.ToLower(args[1].text)]
+		if !ok {
+			return 0, 2, fmt.Errorf("unknown unit %q", args[1].text)
+		}
+		if n <= 0 {
+			return 0, 2, fmt.Errorf("interval must be positive")
+		}
+		return time.Duration(n) * unit, 2, nil
+	}
+
+	d, err := time.ParseDuration(word)
+	if err != nil {
+		return 0, 1, fmt.Errorf("expected a duration such as 6 hours or 90m, got %q", args[0].text)
+	}
+	if d < time.Second {
+		return 0, 1, fmt.Errorf("interval must be at least one second")
+	}
+	return d, 1, nil
+}
+
+// parseRuleTimeOfDay parses a time of day and its optional zone.
+func parseRuleTimeOfDay(args []ruleToken) (time.Duration, *time.Location, int, error) {
+	var t time.Time
+	var err error
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err = time.Parse(layout, args[0].text); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, nil, 1, fmt.Errorf("expected a time of day such as 09:30, got %q", args[0].text)
+	}
+	at := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if len(args) > 1 {
+		loc, ok, err := parseRuleZone(args[1].text)
+		if err != nil {
+			return 0, nil, 2, err
+		}
+		if ok {
+			return at, loc, 2, nil
+		}
+	}
+	return at, time.UTC, 1, nil
+}
+
+// parseRuleDays parses comma separated weekdays or "day N", a day of the month.
+func parseRuleDays(args []ruleToken) ([]time.Weekday, int, int, error) {
+	word := strings.ToLower(args[0].text)
+	if word == "day" {
+		if len(args) < 2 {
+			return nil, 0, 1, fmt.Errorf("missing day of the month")
+		}
+		day, err := strconv.Atoi(args[1].text)
+		if err != nil || day < 1 || day > 31 {
+			return nil, 0, 2, fmt.Errorf("expected a day of the month between 1 and 31, got %q", args[1].text)
+		}
+		return nil, day, 2, nil
+	}
+
+	var days []time.Weekday
+	for _, name := range strings.Split(word, ",") {
+		day, ok := weekdays[name]
+		if !ok {
+			return nil, 0, 1, fmt.Errorf("unknown weekday %q", name)
+		}
+		days = append(days, day)
+	}
+	return days, 0, 1, nil
+}
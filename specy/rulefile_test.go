@@ -0,0 +1,98 @@
+package specy_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuleSchedule(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// the historical form, with rule text around the clause
+	schedule, err := specy.ParseRuleSchedule("count on interchainnft transfers after 2023-01-01T00:00:00+08:00 within 1 days")
+	require.NoError(t, err)
+	require.True(t, schedule.After.Equal(time.Date(2022, 12, 31, 16, 0, 0, 0, time.UTC)))
+	require.False(t, schedule.HasCalendar())
+
+	schedule, err = specy.ParseRuleSchedule("sum rewards after 2023-01-01T09:00:00Z every 6 hours until 2023-02-01 Asia/Shanghai;")
+	require.NoError(t, err)
+	require.True(t, schedule.After.Equal(time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)))
+	require.Equal(t, 6*time.Hour, schedule.Every)
+	require.True(t, schedule.Until.Equal(time.Date(2023, 2, 1, 0, 0, 0, 0, shanghai)))
+
+	schedule, err = specy.ParseRuleSchedule("snapshot after 2023-01-01T00:00:00 Asia/Shanghai every day")
+	require.NoError(t, err)
+	require.True(t, schedule.After.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, shanghai)))
+	require.Equal(t, 24*time.Hour, schedule.Every)
+
+	schedule, err = specy.ParseRuleSchedule("report on mon,fri at 09:30 Asia/Shanghai")
+	require.NoError(t, err)
+	require.Equal(t, "CRON_TZ=Asia/Shanghai 0 30 9 * * 1,5", schedule.CronExpression())
+
+	schedule, err = specy.ParseRuleSchedule("payout on day 1")
+	require.NoError(t, err)
+	require.Equal(t, "CRON_TZ=UTC 0 0 0 1 * *", schedule.CronExpression())
+	_, err = specy.ParseCronSchedule(schedule.CronExpression())
+	require.NoError(t, err)
+
+	// the keywords followed by arguments of an unknown unit or format are rule text
+	for _, rule := range []string{
+		"sum transfers every 100 blocks",
+		"reward at 5 validators",
+		"count every 3 fortnights",
+		"mint after 10 transfers until 20 transfers",
+	} {
+		schedule, err = specy.ParseRuleSchedule(rule)
+		require.NoError(t, err, rule)
+		require.Equal(t, &specy.RuleSchedule{}, schedule, rule)
+	}
+
+	for _, tc := range []struct {
+		rule   string
+		clause string
+		offset int
+	}{
+		{"count after 2023-13-01T00:00:00Z", specy.ClauseAfter, 6},
+		{"count after 2023-01-01T00:00:00 Mars/Base", specy.ClauseAfter, 6},
+		{"count every 0 hours", specy.ClauseEvery, 6},
+		{"count at 25:00", specy.ClauseAt, 6},
+		{"count on day 32", specy.ClauseOn, 6},
+		{"count after 2023-01-01 after 2023-02-01", specy.ClauseAfter, 23},
+		{"count every 1 hour at 09:00", specy.ClauseEvery, 0},
+		{"count after 2023-02-01 until 2023-01-01", specy.ClauseUntil, 0},
+	} {
+		_, err := specy.ParseRuleSchedule(tc.rule)
+		var parseErr *specy.ParseError
+		require.True(t, errors.As(err, &parseErr), tc.rule)
+		require.Equal(t, tc.clause, parseErr.Clause, tc.rule)
+		require.Equal(t, tc.offset, parseErr.Offset, tc.rule)
+	}
+}
+
+func TestRuleScheduleApplyTo(t *testing.T) {
+	schedule, err := specy.ParseRuleSchedule("after 2023-01-01T00:00:00Z")
+	require.NoError(t, err)
+	condition := specy.Condition{IntervalType: specy.IntervalTypeTimeInterval}
+	schedule.ApplyTo(&condition)
+	require.Equal(t, 24*60*60, condition.Interval)
+	require.True(t, condition.StartTime.Equal(schedule.After))
+
+	// an explicit interval takes precedence over the rule file
+	schedule, err = specy.ParseRuleSchedule("every 2 hours")
+	require.NoError(t, err)
+	condition = specy.Condition{IntervalType: specy.IntervalTypeTimeInterval, Interval: 60}
+	schedule.ApplyTo(&condition)
+	require.Equal(t, 60, condition.Interval)
+
+	schedule, err = specy.ParseRuleSchedule("at 09:00")
+	require.NoError(t, err)
+	condition = specy.Condition{IntervalType: specy.IntervalTypeTimeInterval}
+	schedule.ApplyTo(&condition)
+	require.Equal(t, specy.IntervalTypeCron, condition.IntervalType)
+	require.Equal(t, "CRON_TZ=UTC 0 0 9 * * *", condition.CronExpression)
+}
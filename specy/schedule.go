@@ -0,0 +1,68 @@
+package specy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser accepts the standard 5-field cron syntax, an optional leading seconds field
+// and descriptors such as @daily. A CRON_TZ= or TZ= prefix selects the time zone.
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ParseCronSchedule parses a cron expression.
+// Expressions without a time zone prefix are evaluated in UTC,
+// so that every executor computes the same schedule regardless of its local zone.
+func ParseCronSchedule(expr string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+	if !strings.HasPrefix(expr, "TZ=") && !strings.HasPrefix(expr, "CRON_TZ=") {
+		expr = "CRON_TZ=UTC " + expr
+	}
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return schedule, nil
+}
+
+// intervalSchedule activates at start and then every interval.
+type intervalSchedule struct {
+	start time.Time
+	every time.Duration
+}
+
+// Next returns the first activation strictly after t.
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.start) {
+		return s.start
+	}
+	n := t.Sub(s.start)/s.every + 1
+	return s.start.Add(n * s.every)
+}
+
+// windowSchedule restricts the activations of a schedule to the ones at or after start
+// and, when end is not zero, at or before end.
+type windowSchedule struct {
+	cron.Schedule
+	start, end time.Time
+}
+
+// Next returns the first activation strictly after t within the window,
+// or the zero time if there is none.
+func (s windowSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.start) {
+		t = s.start.Add(-time.Nanosecond)
+	}
+	next := s.Schedule.Next(t)
+	if !s.end.IsZero() && next.After(s.end) {
+		return time.Time{}
+	}
+	return next
+}
@@ -0,0 +1,50 @@
+package specy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	// Friday 2023-06-02 10:00 UTC
+	now := time.Date(2023, 6, 2, 10, 0, 0, 0, time.UTC)
+
+	// every weekday at 09:00 UTC, next activation is on Monday
+	schedule, err := specy.ParseCronSchedule("0 9 * * 1-5")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 6, 5, 9, 0, 0, 0, time.UTC), schedule.Next(now).UTC())
+
+	// first day of each month in an explicit time zone
+	schedule, err = specy.ParseCronSchedule("CRON_TZ=Asia/Shanghai 0 0 1 * *")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 6, 30, 16, 0, 0, 0, time.UTC), schedule.Next(now).UTC())
+
+	// optional seconds field
+	schedule, err = specy.ParseCronSchedule("30 0 9 * * *")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 6, 3, 9, 0, 30, 0, time.UTC), schedule.Next(now).UTC())
+
+	// descriptors
+	schedule, err = specy.ParseCronSchedule("@daily")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 6, 3, 0, 0, 0, 0, time.UTC), schedule.Next(now).UTC())
+
+	for _, expr := range []string{"", "every day", "61 * * * *", "CRON_TZ=Mars/Base 0 9 * * *"} {
+		_, err := specy.ParseCronSchedule(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestRegisterRejectsInvalidCron(t *testing.T) {
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, nil)
+
+	task := specy.NewTask("invalid-cron", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeCron, 0, time.Time{})
+	task.Condition.CronExpression = "not a cron"
+	require.Error(t, scheduler.Register(task))
+	require.Empty(t, scheduler.List())
+}
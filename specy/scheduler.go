@@ -1,174 +1,854 @@
 package specy
 
 import (
+	"context"
 	"fmt"
-	"github.com/cosmos/relayer/v2/specy/executor"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
 )
 
-var (
-	everyBlockTasks            = make(map[string]*Task)
-	timeIntervalTaskGoroutines = make(map[string]chan struct{})
+// Clock modes select the clock time based tasks are scheduled against.
+const (
+	// ClockModeLocal schedules time based tasks against the local wall clock.
+	ClockModeLocal = "local"
+	// ClockModeChain schedules time based tasks against the time of the processed blocks,
+	// so that every executor computes the same activations.
+	ClockModeChain = "chain"
 )
 
-type Task struct {
-	TaskHash     string
-	TaskName     string
-	Creator      string
-	ConnectionId string
-	Msgs         string
-	RuleFile     string
-	TaskType     string
+// Trigger describes the activation a task execution runs for.
+type Trigger struct {
+	// Height is the chain height that triggered the execution, zero for activations of the local clock.
+	Height int64
+	// Time is the scheduled time of the activation.
+	Time time.Time
+	// Event is the chain event that triggered an on_event task, nil for other tasks.
+	Event *Event
+}
+
+// TaskExecutor executes a single triggered task, and fills in the engine and transaction details
+// of the execution record. A non nil error marks the execution as failed, and skips the tasks depending on it.
+type TaskExecutor func(ctx context.Context, task *Task, trigger Trigger, record *ExecutionRecord) error
+
+// Scheduler owns the registered tasks and the triggers driving them.
+// It is safe for concurrent use.
+type Scheduler struct {
+	ctx     context.Context
+	log     *zap.Logger
+	store   *TaskStore
+	execute TaskExecutor
+
+	// pool runs the triggered executions, nil to run them on the triggering goroutine.
+	pool *WorkerPool
+
+	// querier answers the queries of task guards.
+	querier GuardQuerier
+
+	// ownership selects the tasks executed by this replica, nil to execute every task.
+	ownership Ownership
+	// shardKeys maps the tasks linked by dependencies to the task hash their ownership is decided by,
+	// see updateShardKeys. The other tasks are owned by their own hash.
+	shardKeys map[string]string
+
+	metrics *Metrics
+
+	// misfirePolicy and maxMissedRuns apply to the tasks that do not set their own.
+	misfirePolicy string
+	maxMissedRuns int
+
+	clockMode string
+
+	// historyMaxRecords and historyMaxAge bound the execution history kept per task.
+	historyMaxRecords int
+	historyMaxAge     time.Duration
+	// ledgerMaxAge bounds how long completed executions are remembered to skip their replays.
+	ledgerMaxAge time.Duration
+
+	mu sync.Mutex
+
+	// height and blockTime are the height and time of the last block handed to TriggerBlockTasks.
+	height    int64
+	blockTime time.Time
+
+	// tasks holds every registered task by hash.
+	tasks map[string]*Task
 
-	Condition Condition
+	// blockTasks holds the tasks triggered by new blocks.
+	blockTasks map[string]*Task
+
+	// eventTasks holds the tasks triggered by chain events.
+	eventTasks map[string]*Task
+
+	// pending holds the slots dependent tasks wait on their parents for, by dependent task hash.
+	pending map[string]map[string]*pendingSlot
+
+	// running holds the IDs of the executions in progress.
+	running map[string]bool
+
+	// timers holds the stop channels of the goroutines driving time based tasks on the local clock.
+	timers map[string]chan struct{}
+
+	// clockTasks holds the time based tasks driven by the block time on the chain clock.
+	clockTasks map[string]*clockTask
 }
 
-type Condition struct {
-	IntervalType string
-	Interval     int
+// clockTask is a time based task evaluated at every block on the chain clock.
+type clockTask struct {
+	task          *Task
+	schedule      cron.Schedule
+	misfirePolicy string
+	maxMissedRuns int
+
+	// last is the last accounted activation of the task.
+	last time.Time
+	// seen is the time of the last block seen since the task was scheduled, zero before the first one.
+	seen time.Time
+}
 
-	StartTime time.Time
+// NewScheduler returns a Scheduler executing triggered tasks with execute.
+// Registered tasks are persisted to store when it is not nil.
+// Time based triggers stop when ctx is done.
+func NewScheduler(ctx context.Context, log *zap.Logger, store *TaskStore, execute TaskExecutor) *Scheduler {
+	return &Scheduler{
+		ctx:     ctx,
+		log:     log,
+		store:   store,
+		execute: execute,
+
+		misfirePolicy: DefaultMisfirePolicy,
+		maxMissedRuns: DefaultMaxMissedRuns,
+		clockMode:     ClockModeLocal,
+
+		historyMaxRecords: DefaultHistoryMaxRecords,
+		historyMaxAge:     DefaultHistoryMaxAge,
+		ledgerMaxAge:      DefaultExecutionLedgerMaxAge,
+
+		tasks:      make(map[string]*Task),
+		blockTasks: make(map[string]*Task),
+		eventTasks: make(map[string]*Task),
+		pending:    make(map[string]map[string]*pendingSlot),
+		running:    make(map[string]bool),
+		clockTasks: make(map[string]*clockTask),
+		timers:     make(map[string]chan struct{}),
+	}
 }
 
-func NewTask(taskHash string, taskName string, creator string, connectionId string, msgs string, ruleFile string, taskType string, intervalType string, interval int, startTime time.Time) *Task {
+// SetWorkerPool makes the scheduler hand triggered executions to the given pool
+// instead of running them on the triggering goroutine. It must be called before Load.
+func (s *Scheduler) SetWorkerPool(pool *WorkerPool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pool = pool
+}
 
-	return &Task{
-		TaskHash:     taskHash,
-		TaskName:     taskName,
-		Creator:      creator,
-		ConnectionId: connectionId,
-		Msgs:         msgs,
-		RuleFile:     ruleFile,
-		TaskType:     taskType,
+// SetGuardQuerier sets the querier task guards are checked with.
+// It should be called before Load, so that the guards of the caught up activations can be checked.
+func (s *Scheduler) SetGuardQuerier(querier GuardQuerier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.querier = querier
+}
 
-		Condition: Condition{
-			intervalType,
-			interval,
-			startTime,
-		},
-	}
+// guardQuerier returns the querier task guards are checked with.
+func (s *Scheduler) guardQuerier() GuardQuerier {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.querier
 }
 
-func RegisterTask(task *Task) {
-	switch task.Condition.IntervalType {
-	case "time_interval":
-		// 直接触发 task (goroutine)
-		triggerTimeIntervalTask(task)
+// SetOwnership makes the scheduler only execute the tasks owned by this replica.
+// It must be called before Load.
+func (s *Scheduler) SetOwnership(ownership Ownership) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ownership = ownership
+}
 
-	case "every_block":
-		// 将 task 注册到任务列表中 待爬区块的时候遍历触发
-		everyBlockTasks[task.TaskHash] = task
+// SetMetrics sets the metrics the scheduler reports to. It must be called before Load.
+func (s *Scheduler) SetMetrics(metrics *Metrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = metrics
+}
+
+// SetMisfirePolicy sets the misfire policy of the tasks that do not set their own.
+// An empty policy or a non positive maxMissedRuns keeps the current value.
+func (s *Scheduler) SetMisfirePolicy(policy string, maxMissedRuns int) error {
+	if err := ValidateMisfirePolicy(policy); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if policy != "" {
+		s.misfirePolicy = policy
+	}
+	if maxMissedRuns > 0 {
+		s.maxMissedRuns = maxMissedRuns
+	}
+	return nil
+}
+
+// SetClockMode sets the clock time based tasks are scheduled against.
+// It applies to the tasks scheduled afterwards, so it must be called before Load.
+func (s *Scheduler) SetClockMode(mode string) error {
+	switch mode {
+	case "":
+		return nil
+	case ClockModeLocal, ClockModeChain:
 	default:
-		fmt.Println("unsupportted task type")
+		return fmt.Errorf("unsupported clock mode %q", mode)
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockMode = mode
+	return nil
 }
 
-func triggerTimeIntervalTask(task *Task) {
-	stopCh := make(chan struct{})
+// Load restores the tasks persisted in the task store and schedules them again.
+func (s *Scheduler) Load() error {
+	if s.store == nil {
+		return nil
+	}
 
-	// 存储 goroutine 对象
-	timeIntervalTaskGoroutines[task.TaskHash] = stopCh
+	tasks, err := s.store.Tasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
 
-	// 计算距离下一个时间节点的延迟时间
-	delay := task.Condition.StartTime.Sub(time.Now())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range tasks {
+		if err := s.schedule(task); err != nil {
+			s.log.Error("Failed to restore task", zap.String("task_hash", task.TaskHash), zap.Error(err))
+		}
+	}
+	s.updateOwnedTasks()
+	s.log.Info("Restored tasks from task store", zap.Int("count", len(tasks)))
+	return nil
+}
 
-	// 创建一个定时器，在延迟时间到达后开始触发定时任务
-	timer := time.NewTimer(delay)
+// Register schedules the task and persists it, replacing any task registered with the same hash.
+// Tasks with an invalid condition are rejected and neither scheduled nor persisted.
+func (s *Scheduler) Register(task *Task) error {
+	if err := validateTask(task); err != nil {
+		return fmt.Errorf("rejected task %s: %w", task.TaskHash, err)
+	}
 
-	go func() {
-		// goroutine 中定时执行
-		for ; true; <-timer.C {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-			// 在定时任务中执行具体的操作
-			fmt.Println("定时任务触发了！")
+	if err := s.checkDependencies(task); err != nil {
+		return fmt.Errorf("rejected task %s: %w", task.TaskHash, err)
+	}
 
-			executor.ExecuteTask(task)
+	s.unschedule(task.TaskHash)
 
-			// 重新设置定时器，按照时间间隔触发下一次定时任务
-			timer.Reset(time.Duration(task.Condition.Interval) * time.Second)
+	if s.store != nil {
+		if err := s.store.SaveTask(task); err != nil {
+			s.log.Error("Failed to persist task", zap.String("task_hash", task.TaskHash), zap.Error(err))
+		}
+	}
 
-			select {
-			case <-stopCh:
-				fmt.Println(task.TaskHash, "stopped")
-				return
-			default:
-				fmt.Println(task.TaskHash, "running")
-			}
+	// tasks owned by another instance are scheduled all the same, to take them over on a rebalance
+	defer func() {
+		s.updateOwnedTasks()
+		if !s.ownsLocked(task.TaskHash) {
+			s.log.Debug("Registered task owned by another instance", zap.String("task_hash", task.TaskHash))
 		}
 	}()
+	return s.schedule(task)
 }
 
-func TriggerEveryBlockTasks() {
+// Unregister stops the task and removes it from the task store.
+// Its execution history is kept until pruned by PruneHistory.
+func (s *Scheduler) Unregister(taskHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// 将task放到数组中 方便后续操作
-	tasks := make([]*Task, 0, len(everyBlockTasks))
-	for _, task := range everyBlockTasks {
-		tasks = append(tasks, task)
+	s.unschedule(taskHash)
+	s.updateOwnedTasks()
+
+	if s.store != nil {
+		if err := s.store.DeleteTask(taskHash); err != nil {
+			s.log.Error("Failed to delete task", zap.String("task_hash", taskHash), zap.Error(err))
+		}
+	}
+}
+
+// Pause stops the triggers of the task. The task stays registered and persisted with its state.
+func (s *Scheduler) Pause(taskHash string) error {
+	return s.setPaused(taskHash, true)
+}
+
+// Resume arms the triggers of a paused task again.
+// The activations of the task while it was paused are not caught up.
+func (s *Scheduler) Resume(taskHash string) error {
+	return s.setPaused(taskHash, false)
+}
+
+func (s *Scheduler) setPaused(taskHash string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskHash]
+	if !ok {
+		return fmt.Errorf("unknown task %s", taskHash)
+	}
+	if task.Paused == paused {
+		return nil
+	}
+	t := *task
+	t.Paused = paused
+	return s.reschedule(&t)
+}
+
+// Update replaces the condition, rule file, messages and guard of a registered task in place, see Task.Updated.
+// The task keeps its hash, its paused state
+// and its execution history, and is rescheduled from now on.
+// An update with an invalid condition is rejected and leaves the task unchanged.
+func (s *Scheduler) Update(update *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[update.TaskHash]
+	if !ok {
+		return fmt.Errorf("unknown task %s", update.TaskHash)
+	}
+	updated := task.Updated(update)
+	if err := validateTask(updated); err != nil {
+		return fmt.Errorf("rejected update of task %s: %w", updated.TaskHash, err)
+	}
+	if err := s.checkDependencies(updated); err != nil {
+		return fmt.Errorf("rejected update of task %s: %w", updated.TaskHash, err)
+	}
+	defer s.updateOwnedTasks()
+	return s.reschedule(updated)
+}
+
+// reschedule replaces the registered task with the same hash, persists it and arms its triggers.
+// Activations before the current clock time belong to the previous state of the task and are
+// not caught up. The caller must hold s.mu.
+func (s *Scheduler) reschedule(task *Task) error {
+	s.unschedule(task.TaskHash)
+
+	if s.store != nil {
+		if err := s.store.SaveTask(task); err != nil {
+			s.log.Error("Failed to persist task", zap.String("task_hash", task.TaskHash), zap.Error(err))
+		}
+	}
+
+	if now := s.now(); !task.Paused && !now.IsZero() {
+		s.recordLastRun(task.TaskHash, now)
 	}
+	return s.schedule(task)
+}
 
-	// 每个子列表的大小
-	batchSize := 10
+// now returns the current time of the scheduler clock. The caller must hold s.mu.
+func (s *Scheduler) now() time.Time {
+	if s.clockMode == ClockModeChain {
+		return s.blockTime
+	}
+	return time.Now()
+}
 
-	// 计算需要划分的子列表数量
-	numBatches := (len(everyBlockTasks) + batchSize - 1) / batchSize
+// Rebalance accounts for a change of the tasks owned by this instance, e.g. when members
+// joined or left its shard ring. Ownership is checked on every execution, so tasks moved
+// to this instance run from their next activation on.
+func (s *Scheduler) Rebalance() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// 分批处理列表
-	for i := 0; i < numBatches; i++ {
-		start := i * batchSize
-		end := (i + 1) * batchSize
+	owned := s.updateOwnedTasks()
+	s.log.Info("Rebalanced tasks", zap.Int("owned", owned), zap.Int("registered", len(s.tasks)))
+}
 
-		if end > len(everyBlockTasks) {
-			end = len(everyBlockTasks)
+// updateOwnedTasks groups the tasks linked by dependencies, then reports the number of registered tasks
+// owned by this instance and returns it. The caller must hold s.mu.
+func (s *Scheduler) updateOwnedTasks() int {
+	s.updateShardKeys()
+	owned := len(s.tasks)
+	if s.ownership != nil {
+		owned = 0
+		for hash := range s.tasks {
+			if s.ownsLocked(hash) {
+				owned++
+			}
 		}
+	}
+	s.metrics.SetOwnedTasks(owned)
+	return owned
+}
+
+// List returns a copy of every registered task, ordered by task hash.
+func (s *Scheduler) List() []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]*Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		t := *task
+		tasks = append(tasks, &t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].TaskHash < tasks[j].TaskHash })
+	return tasks
+}
+
+// Get returns a copy of the registered task with the given hash.
+func (s *Scheduler) Get(taskHash string) (*Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskHash]
+	if !ok {
+		return nil, false
+	}
+	t := *task
+	return &t, true
+}
 
-		processTriggerEveryBlockTask(tasks[start:end])
+// SetLastProcessedHeight records the last chain height whose events were handed to the scheduler.
+func (s *Scheduler) SetLastProcessedHeight(height int64) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.SetLastHeight(height); err != nil {
+		s.log.Error("Failed to persist last processed height", zap.Int64("height", height), zap.Error(err))
 	}
 }
 
-func processTriggerEveryBlockTask(tasks []*Task) {
-	var wg sync.WaitGroup
-	defer wg.Done()
+// LastProcessedHeight returns the last chain height whose events were handed to the scheduler,
+// as recorded before a restart, or 0 if none was recorded.
+func (s *Scheduler) LastProcessedHeight() int64 {
+	if s.store == nil {
+		return 0
+	}
+	height, err := s.store.LastHeight()
+	if err != nil {
+		s.log.Error("Failed to load last processed height", zap.Error(err))
+	}
+	return height
+}
+
+// TriggerBlockTasks dispatches the block based tasks that are due at the given height.
+// On the chain clock, it also dispatches the time based tasks with activations
+// up to the given block time.
+func (s *Scheduler) TriggerBlockTasks(height int64, blockTime time.Time) {
+	type execution struct {
+		task    *Task
+		trigger Trigger
+		missed  bool
+	}
+
+	s.mu.Lock()
+	// 将task放到数组中 方便后续操作
+	var executions []execution
+	for _, task := range s.blockTasks {
+		if task.Condition.dueAtHeight(height) {
+			executions = append(executions, execution{task, Trigger{Height: height, Time: blockTime}, false})
+		}
+	}
+	for _, ct := range s.clockTasks {
+		slots, missed := s.dueActivations(ct, blockTime)
+		for _, slot := range slots {
+			executions = append(executions, execution{ct.task, Trigger{Height: height, Time: slot}, missed})
+		}
+	}
+	s.height = height
+	s.blockTime = blockTime
+	s.mu.Unlock()
+
+	for _, e := range executions {
+		if e.missed {
+			s.dispatchMissed(e.task, e.trigger)
+		} else {
+			s.dispatch(e.task, e.trigger)
+		}
+	}
+}
 
-	var itemWg sync.WaitGroup
+// TriggerEventTasks dispatches the on_event tasks matching a chain event emitted at the given height.
+func (s *Scheduler) TriggerEventTasks(height int64, blockTime time.Time, event Event) {
+	s.mu.Lock()
+	var tasks []*Task
+	for _, task := range s.eventTasks {
+		if task.Condition.inHeightWindow(height) && task.Condition.matchesEvent(event) {
+			tasks = append(tasks, task)
+		}
+	}
+	s.mu.Unlock()
 
 	for _, task := range tasks {
-		itemWg.Add(1)
+		s.dispatch(task, Trigger{Height: height, Time: blockTime, Event: &event})
+	}
+}
 
-		go func(task *Task) {
-			defer itemWg.Done()
+// dueActivations returns the activations of a task on the chain clock reached at blockTime,
+// and accounts for them. The most recent activation runs when it falls after the previous block,
+// the earlier ones were missed and only run with the run_all_missed policy. The activations reached
+// by the first block seen since the task was scheduled were missed while the scheduler was not running,
+// and are handled by the misfire policy as on the local clock, which is reported. The caller must hold s.mu.
+func (s *Scheduler) dueActivations(ct *clockTask, blockTime time.Time) ([]time.Time, bool) {
+	seen := ct.seen
+	ct.seen = blockTime
+	if ct.last.IsZero() {
+		// first block seen since the task was scheduled, activations start from there
+		ct.last = blockTime
+		return nil, false
+	}
 
-			fmt.Println("Processing task:", task)
-			executor.ExecuteTask(task)
+	slots, missed := missedActivations(ct.schedule, ct.last, blockTime, ct.maxMissedRuns)
+	if missed == 0 {
+		return nil, false
+	}
+	latest := slots[len(slots)-1]
+	ct.last = latest
+
+	if !seen.IsZero() && latest.After(seen) {
+		// the most recent activation is on time
+		if missed > 1 {
+			s.log.Info(
+				"Task missed activations",
+				zap.String("task_hash", ct.task.TaskHash),
+				zap.Int("missed", missed-1),
+				zap.Time("block_time", blockTime),
+				zap.String("misfire_policy", ct.misfirePolicy),
+			)
+		}
+		if ct.misfirePolicy == MisfirePolicyRunAllMissed {
+			return slots, false
+		}
+		return slots[len(slots)-1:], false
+	}
 
-		}(task)
+	s.log.Info(
+		"Task missed activations",
+		zap.String("task_hash", ct.task.TaskHash),
+		zap.Int("missed", missed),
+		zap.Time("block_time", blockTime),
+		zap.String("misfire_policy", ct.misfirePolicy),
+	)
+	switch ct.misfirePolicy {
+	case MisfirePolicySkip:
+		// account for the skipped activations so that they are not considered missed again
+		s.recordLastRun(ct.task.TaskHash, latest)
+		return nil, true
+	case MisfirePolicyRunAllMissed:
+		return slots, true
+	default:
+		return slots[len(slots)-1:], true
 	}
+}
 
-	itemWg.Wait()
+// dispatch submits the execution of the task for the trigger.
+// Tasks owned by another replica are accounted for as run without being executed,
+// so that the activations they handled are not caught up on a takeover.
+func (s *Scheduler) dispatch(task *Task, trigger Trigger) {
+	if !s.owns(task.TaskHash) {
+		s.log.Debug("Task owned by another replica, skipping", zap.String("task_hash", task.TaskHash))
+		s.metrics.IncExecutionsRejected(rejectReasonNotOwner)
+		s.recordLastRun(task.TaskHash, trigger.Time)
+		return
+	}
+	s.submit(task, trigger)
 }
 
-func UnregisterTask(taskHash string) {
-	if everyBlockTasks[taskHash] != nil {
-		removeEveryBlockTask(taskHash)
-	} else {
-		stopTimeIntervalTaskGoroutine(taskHash)
+// dispatchMissed submits the execution of an activation of the task missed while the scheduler was not running.
+// Unlike dispatch, the activations of tasks owned by another replica are not accounted for as run,
+// as they are caught up by their owner and this replica may not know its ownership yet.
+func (s *Scheduler) dispatchMissed(task *Task, trigger Trigger) {
+	if !s.owns(task.TaskHash) {
+		s.log.Debug("Missed activation of task owned by another replica, skipping", zap.String("task_hash", task.TaskHash))
+		s.metrics.IncExecutionsRejected(rejectReasonNotOwner)
+		return
 	}
+	s.submit(task, trigger)
 }
 
-func removeEveryBlockTask(hash string) {
-	// 从 map 中移除 task
-	delete(everyBlockTasks, hash)
+// submit hands the execution of the task for the trigger to the worker pool,
+// or runs it right away when the scheduler has no pool.
+func (s *Scheduler) submit(task *Task, trigger Trigger) {
+	if s.pool == nil {
+		s.runTask(s.ctx, task, trigger)
+		return
+	}
+	s.pool.Submit(task, func(ctx context.Context) {
+		s.runTask(ctx, task, trigger)
+	})
 }
 
-func stopTimeIntervalTaskGoroutine(taskHash string) {
-	stopCh, ok := timeIntervalTaskGoroutines[taskHash]
+// schedule arms the trigger of the task. The caller must hold s.mu.
+func (s *Scheduler) schedule(task *Task) error {
+	if task.Paused {
+		// paused tasks stay registered without any trigger
+		s.tasks[task.TaskHash] = task
+		return nil
+	}
+
+	switch task.Condition.IntervalType {
+	case IntervalTypeTimeInterval, IntervalTypeCron:
+		schedule, err := timeSchedule(task)
+		if err != nil {
+			return err
+		}
+		s.scheduleTime(task, schedule)
+
+	case IntervalTypeEveryBlock, IntervalTypeEveryNBlocks:
+		// 将 task 注册到任务列表中 待爬区块的时候遍历触发
+		s.blockTasks[task.TaskHash] = task
+
+	case IntervalTypeOnEvent:
+		s.eventTasks[task.TaskHash] = task
+
+	case IntervalTypeDependent:
+		// triggered by the completion of the tasks it depends on
+
+	default:
+		return fmt.Errorf("unsupported task interval type %q", task.Condition.IntervalType)
+	}
+
+	s.tasks[task.TaskHash] = task
+	return nil
+}
+
+// timeSchedule returns the activations of a time based task, bounded by its start and end times.
+func timeSchedule(task *Task) (cron.Schedule, error) {
+	var schedule cron.Schedule
+	switch task.Condition.IntervalType {
+	case IntervalTypeTimeInterval:
+		schedule = intervalSchedule{
+			start: task.Condition.StartTime,
+			every: time.Duration(task.Condition.Interval) * time.Second,
+		}
+	case IntervalTypeCron:
+		var err error
+		if schedule, err = ParseCronSchedule(task.Condition.CronExpression); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("task interval type %q is not time based", task.Condition.IntervalType)
+	}
+	return windowSchedule{
+		Schedule: schedule,
+		start:    task.Condition.StartTime,
+		end:      task.Condition.EndTime,
+	}, nil
+}
+
+// scheduleTime arms a time based task on the configured clock. The caller must hold s.mu.
+func (s *Scheduler) scheduleTime(task *Task, schedule cron.Schedule) {
+	if s.clockMode != ClockModeChain {
+		s.startTimer(task, schedule)
+		return
+	}
+
+	policy, maxMissedRuns := s.misfirePolicyOf(task)
+	last := s.lastRun(task.TaskHash)
+	if last.IsZero() {
+		last = s.blockTime
+	}
+	s.clockTasks[task.TaskHash] = &clockTask{
+		task:          task,
+		schedule:      schedule,
+		misfirePolicy: policy,
+		maxMissedRuns: maxMissedRuns,
+		last:          last,
+		seen:          s.blockTime,
+	}
+}
+
+// misfirePolicyOf returns the misfire policy of the task, defaulting to the scheduler one.
+// The caller must hold s.mu.
+func (s *Scheduler) misfirePolicyOf(task *Task) (string, int) {
+	policy, maxMissedRuns := task.Condition.MisfirePolicy, task.Condition.MaxMissedRuns
+	if policy == "" {
+		policy = s.misfirePolicy
+	}
+	if maxMissedRuns <= 0 {
+		maxMissedRuns = s.maxMissedRuns
+	}
+	return policy, maxMissedRuns
+}
+
+// unschedule disarms the trigger of the task. The caller must hold s.mu.
+func (s *Scheduler) unschedule(taskHash string) {
+	delete(s.tasks, taskHash)
+	delete(s.blockTasks, taskHash)
+	delete(s.eventTasks, taskHash)
+	delete(s.pending, taskHash)
+	delete(s.clockTasks, taskHash)
+
+	if stopCh, ok := s.timers[taskHash]; ok {
+		// 关闭 stop channel 以停止 goroutine
+		close(stopCh)
+		delete(s.timers, taskHash)
+	}
+}
+
+// startTimer starts a goroutine running the task at every activation of the schedule,
+// until the task is unscheduled or the scheduler context is done.
+// Activations missed since the last recorded run are handled by the task misfire policy.
+// The caller must hold s.mu.
+func (s *Scheduler) startTimer(task *Task, schedule cron.Schedule) {
+	stopCh := make(chan struct{})
+	s.timers[task.TaskHash] = stopCh
+
+	policy, maxMissedRuns := s.misfirePolicyOf(task)
+	last := s.lastRun(task.TaskHash)
+
+	go func() {
+		for {
+			now := time.Now()
+			if !last.IsZero() {
+				if caughtUp := s.catchUp(task, schedule, policy, maxMissedRuns, last, now); !caughtUp.IsZero() {
+					last = caughtUp
+				}
+			}
+
+			next := schedule.Next(now)
+			if next.IsZero() {
+				s.log.Info("Task schedule has no further activations", zap.String("task_hash", task.TaskHash))
+				return
+			}
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			case <-stopCh:
+				timer.Stop()
+				s.log.Debug("Task timer stopped", zap.String("task_hash", task.TaskHash))
+				return
+			case <-timer.C:
+				s.dispatch(task, Trigger{Time: next})
+				last = next
+			}
+		}
+	}()
+}
+
+// catchUp applies the misfire policy to the activations of the task missed after last and up to now.
+// It returns the most recent missed activation, or the zero time if none was missed.
+func (s *Scheduler) catchUp(task *Task, schedule cron.Schedule, policy string, maxMissedRuns int, last, now time.Time) time.Time {
+	slots, missed := missedActivations(schedule, last, now, maxMissedRuns)
+	if missed == 0 {
+		return time.Time{}
+	}
+	latest := slots[len(slots)-1]
+
+	s.log.Info(
+		"Task missed activations",
+		zap.String("task_hash", task.TaskHash),
+		zap.Int("missed", missed),
+		zap.Time("last_run", last),
+		zap.String("misfire_policy", policy),
+	)
+
+	switch policy {
+	case MisfirePolicySkip:
+		// account for the skipped activations so that they are not considered missed again
+		s.recordLastRun(task.TaskHash, latest)
+	case MisfirePolicyRunAllMissed:
+		for _, slot := range slots {
+			s.dispatchMissed(task, Trigger{Time: slot})
+		}
+	default:
+		s.dispatchMissed(task, Trigger{Time: latest})
+	}
+	return latest
+}
+
+// lastRun returns the activation of the last recorded execution of the task,
+// or the zero time if none was recorded.
+func (s *Scheduler) lastRun(taskHash string) time.Time {
+	if s.store == nil {
+		return time.Time{}
+	}
+	last, ok, err := s.store.LastExecution(taskHash)
+	if err != nil {
+		s.log.Error("Failed to load last execution", zap.String("task_hash", taskHash), zap.Error(err))
+	}
 	if !ok {
+		return time.Time{}
+	}
+	return last
+}
+
+// recordLastRun persists slot as the last run of the task, unless a later run is already recorded.
+func (s *Scheduler) recordLastRun(taskHash string, slot time.Time) {
+	if s.store == nil {
+		return
+	}
+	if last := s.lastRun(taskHash); last.After(slot) {
+		return
+	}
+	if err := s.store.SetLastExecution(taskHash, slot); err != nil {
+		s.log.Error("Failed to persist last execution", zap.String("task_hash", taskHash), zap.Error(err))
+	}
+}
+
+// runTask executes the task for the trigger, records its activation as the last run
+// and its outcome in the execution history.
+func (s *Scheduler) runTask(ctx context.Context, task *Task, trigger Trigger) {
+	if s.execute == nil {
+		s.log.Warn("No task executor configured, skipping task", zap.String("task_hash", task.TaskHash))
 		return
 	}
 
-	// 关闭 stop channel 以停止 goroutine
-	close(stopCh)
+	executionID := ExecutionID(task.TaskHash, trigger)
+	if !s.startExecution(task.TaskHash, executionID) {
+		return
+	}
+	succeeded := false
+	defer func() { s.finishExecution(task.TaskHash, executionID, succeeded) }()
+
+	record := &ExecutionRecord{
+		ExecutionID:   executionID,
+		TaskHash:      task.TaskHash,
+		TriggerHeight: trigger.Height,
+		TriggerTime:   trigger.Time.UTC(),
+		StartedAt:     time.Now().UTC(),
+	}
+	if trigger.Event != nil {
+		record.TriggerEvent = trigger.Event.Type
+	}
+
+	if task.Guard != nil {
+		ok, err := task.Guard.Check(ctx, s.guardQuerier(), trigger)
+		if err != nil {
+			s.log.Error("Failed to check task guard, skipping task", zap.String("task_hash", task.TaskHash), zap.Error(err))
+			s.metrics.IncExecutionsRejected(rejectReasonGuardError)
+			s.recordExecution(record, ExecutionStatusSkipped, err)
+			s.completeDependencies(task, trigger, err)
+			return
+		}
+		if !ok {
+			err := fmt.Errorf("guard of task %s does not hold", task.TaskHash)
+			s.log.Info("Task guard does not hold, skipping task", zap.String("task_hash", task.TaskHash))
+			s.metrics.IncExecutionsRejected(rejectReasonGuardNotMet)
+			s.recordLastRun(task.TaskHash, trigger.Time)
+			s.recordExecution(record, ExecutionStatusSkipped, err)
+			s.completeDependencies(task, trigger, err)
+			return
+		}
+	}
+
+	s.log.Info("Executing task", zap.String("task_hash", task.TaskHash), zap.String("task_name", task.TaskName))
+	err := s.execute(ctx, task, trigger, record)
+	if err != nil {
+		s.log.Error("Task execution failed", zap.String("task_hash", task.TaskHash), zap.Error(err))
+		s.recordExecution(record, ExecutionStatusFailed, err)
+	} else {
+		s.recordExecution(record, ExecutionStatusSucceeded, nil)
+	}
+	succeeded = err == nil
 
-	// 从 map 中移除 goroutine
-	delete(timeIntervalTaskGoroutines, taskHash)
+	s.recordLastRun(task.TaskHash, trigger.Time)
+	s.completeDependencies(task, trigger, err)
 }
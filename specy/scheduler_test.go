@@ -0,0 +1,300 @@
+package specy_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// executionRecorder is a specy.TaskExecutor recording the executed task hashes and their triggers.
+// The executions of the tasks in failing fail.
+type executionRecorder struct {
+	mu       sync.Mutex
+	executed []string
+	triggers []specy.Trigger
+	failing  map[string]bool
+}
+
+func (r *executionRecorder) execute(_ context.Context, task *specy.Task, trigger specy.Trigger, record *specy.ExecutionRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executed = append(r.executed, task.TaskHash)
+	r.triggers = append(r.triggers, trigger)
+	record.TxHash = "tx-" + task.TaskHash
+	if r.failing[task.TaskHash] {
+		return fmt.Errorf("execution of %s failed", task.TaskHash)
+	}
+	return nil
+}
+
+func (r *executionRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	executed := r.executed
+	r.executed, r.triggers = nil, nil
+	return executed
+}
+
+func (r *executionRecorder) takeTriggers() []specy.Trigger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	triggers := r.triggers
+	r.executed, r.triggers = nil, nil
+	return triggers
+}
+
+func TestTriggerBlockTasksHeightWindow(t *testing.T) {
+	recorder := new(executionRecorder)
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorder.execute)
+
+	everyHundred := specy.NewTask("every-hundred", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryNBlocks, 100, time.Time{})
+	everyHundred.Condition.StartHeight = 1050
+	everyHundred.Condition.EndHeight = 1350
+	require.NoError(t, scheduler.Register(everyHundred))
+
+	everyBlock := specy.NewTask("every-block", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+	everyBlock.Condition.EndHeight = 1100
+	require.NoError(t, scheduler.Register(everyBlock))
+
+	runs := make(map[string][]int64)
+	for _, height := range []int64{1000, 1050, 1100, 1101, 1150, 1250, 1350, 1450} {
+		scheduler.TriggerBlockTasks(height, time.Time{})
+		for _, hash := range recorder.take() {
+			runs[hash] = append(runs[hash], height)
+		}
+	}
+
+	require.Equal(t, []int64{1050, 1150, 1250, 1350}, runs["every-hundred"])
+	require.Equal(t, []int64{1000, 1050, 1100}, runs["every-block"])
+}
+
+func TestTriggerEventTasks(t *testing.T) {
+	recorder := new(executionRecorder)
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorder.execute)
+
+	task := specy.NewTask("on-transfer", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeOnEvent, 0, time.Time{})
+	task.Condition.EventType = "transfer"
+	task.Condition.EventPredicates = []specy.EventPredicate{
+		{Key: "recipient", Value: "cosmos1treasury"},
+		{Key: "amount", Op: specy.PredicateOpContains, Value: "uatom"},
+	}
+	task.Condition.EndHeight = 20
+	require.NoError(t, scheduler.Register(task))
+
+	transfer := func(recipient, amount string) specy.Event {
+		return specy.Event{Type: "transfer", Attributes: []specy.EventAttribute{
+			{Key: "sender", Value: "cosmos1alice"},
+			{Key: "recipient", Value: recipient},
+			{Key: "amount", Value: amount},
+		}}
+	}
+	blockTime := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	scheduler.TriggerEventTasks(10, blockTime, transfer("cosmos1bob", "100uatom"))
+	scheduler.TriggerEventTasks(10, blockTime, transfer("cosmos1treasury", "100uosmo"))
+	scheduler.TriggerEventTasks(10, blockTime, specy.Event{Type: "wasm", Attributes: []specy.EventAttribute{{Key: "recipient", Value: "cosmos1treasury"}}})
+	require.Empty(t, recorder.take())
+
+	matching := transfer("cosmos1treasury", "100uatom")
+	scheduler.TriggerEventTasks(10, blockTime, matching)
+	require.Equal(t, []specy.Trigger{{Height: 10, Time: blockTime, Event: &matching}}, recorder.takeTriggers())
+
+	// outside of the height window
+	scheduler.TriggerEventTasks(21, blockTime, matching)
+	require.Empty(t, recorder.take())
+}
+
+func TestChainClockSchedule(t *testing.T) {
+	start := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+	blocks := []struct {
+		height int64
+		time   time.Time
+	}{
+		{100, start.Add(-10 * time.Second)},
+		{101, start.Add(5 * time.Second)},
+		{102, start.Add(50 * time.Second)},
+		{103, start.Add(3*time.Minute + 5*time.Second)},
+	}
+
+	replay := func() []specy.Trigger {
+		recorder := new(executionRecorder)
+		scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorder.execute)
+		require.NoError(t, scheduler.SetClockMode(specy.ClockModeChain))
+		require.NoError(t, scheduler.Register(specy.NewTask("minutely", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeTimeInterval, 60, start)))
+
+		for _, block := range blocks {
+			scheduler.TriggerBlockTasks(block.height, block.time)
+		}
+		return recorder.takeTriggers()
+	}
+
+	// the activation reached by a block runs once, the earlier missed ones are skipped with the default run_once policy
+	triggers := replay()
+	require.Equal(t, []specy.Trigger{
+		{Height: 101, Time: start},
+		{Height: 103, Time: start.Add(3 * time.Minute)},
+	}, triggers)
+
+	// replaying the same blocks reproduces the same runs
+	require.Equal(t, triggers, replay())
+}
+
+func TestSchedulerRegisterListGet(t *testing.T) {
+	store := specy.NewTaskStore(dbm.NewMemDB())
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), store, nil)
+
+	for _, hash := range []string{"b", "a", "c"} {
+		require.NoError(t, scheduler.Register(specy.NewTask(hash, "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})))
+	}
+	scheduler.Unregister("c")
+
+	tasks := scheduler.List()
+	require.Len(t, tasks, 2)
+	require.Equal(t, "a", tasks[0].TaskHash)
+	require.Equal(t, "b", tasks[1].TaskHash)
+
+	task, ok := scheduler.Get("a")
+	require.True(t, ok)
+	require.Equal(t, "a", task.TaskHash)
+	_, ok = scheduler.Get("c")
+	require.False(t, ok)
+
+	// a new scheduler on the same store restores the registered tasks
+	restored := specy.NewScheduler(context.Background(), zap.NewNop(), store, nil)
+	require.NoError(t, restored.Load())
+	require.Equal(t, tasks, restored.List())
+}
+
+func TestSchedulerConcurrentAccess(t *testing.T) {
+	recorder := new(executionRecorder)
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorder.execute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				hash := fmt.Sprintf("task-%d-%d", i, j)
+				require.NoError(t, scheduler.Register(specy.NewTask(hash, "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})))
+				if j%2 == 0 {
+					scheduler.Unregister(hash)
+				}
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for height := int64(1); height <= 50; height++ {
+				scheduler.TriggerBlockTasks(height, time.Time{})
+				_ = scheduler.List()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, scheduler.List(), 8*25)
+}
+
+func TestValidateCondition(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		condition specy.Condition
+		valid     bool
+	}{
+		{"time interval", specy.Condition{IntervalType: specy.IntervalTypeTimeInterval, Interval: 60}, true},
+		{"zero time interval", specy.Condition{IntervalType: specy.IntervalTypeTimeInterval}, false},
+		{"every block", specy.Condition{IntervalType: specy.IntervalTypeEveryBlock}, true},
+		{"every n blocks", specy.Condition{IntervalType: specy.IntervalTypeEveryNBlocks, Interval: 10}, true},
+		{"every zero blocks", specy.Condition{IntervalType: specy.IntervalTypeEveryNBlocks}, false},
+		{"inverted window", specy.Condition{IntervalType: specy.IntervalTypeEveryBlock, StartHeight: 10, EndHeight: 5}, false},
+		{"unknown type", specy.Condition{IntervalType: "sometimes"}, false},
+		{"on event", specy.Condition{IntervalType: specy.IntervalTypeOnEvent, EventType: "transfer", EventPredicates: []specy.EventPredicate{{Key: "recipient", Op: specy.PredicateOpExists}}}, true},
+		{"on event without type", specy.Condition{IntervalType: specy.IntervalTypeOnEvent}, false},
+		{"unknown predicate operator", specy.Condition{IntervalType: specy.IntervalTypeOnEvent, EventType: "transfer", EventPredicates: []specy.EventPredicate{{Key: "amount", Op: "gt"}}}, false},
+		{"unknown misfire policy", specy.Condition{IntervalType: specy.IntervalTypeEveryBlock, MisfirePolicy: "sometimes"}, false},
+	} {
+		err := specy.ValidateCondition(tc.condition)
+		if tc.valid {
+			require.NoError(t, err, tc.name)
+		} else {
+			require.Error(t, err, tc.name)
+		}
+	}
+}
+
+func TestSchedulerPauseResumeUpdate(t *testing.T) {
+	start := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+	store := specy.NewTaskStore(dbm.NewMemDB())
+	recorder := new(executionRecorder)
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), store, recorder.execute)
+	require.NoError(t, scheduler.SetClockMode(specy.ClockModeChain))
+
+	task := specy.NewTask("minutely", "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeTimeInterval, 60, start)
+	task.Condition.MisfirePolicy = specy.MisfirePolicyRunAllMissed
+	require.NoError(t, scheduler.Register(task))
+
+	scheduler.TriggerBlockTasks(99, start.Add(-5*time.Second))
+	scheduler.TriggerBlockTasks(100, start.Add(5*time.Second))
+	require.Equal(t, []specy.Trigger{{Height: 100, Time: start}}, recorder.takeTriggers())
+
+	// a paused task is not triggered, and stays paused across restarts
+	require.NoError(t, scheduler.Pause("minutely"))
+	scheduler.TriggerBlockTasks(101, start.Add(2*time.Minute+5*time.Second))
+	require.Empty(t, recorder.takeTriggers())
+
+	restored := specy.NewScheduler(context.Background(), zap.NewNop(), store, nil)
+	require.NoError(t, restored.Load())
+	paused, ok := restored.Get("minutely")
+	require.True(t, ok)
+	require.True(t, paused.Paused)
+
+	// a resumed task does not catch up on the activations missed while paused
+	require.NoError(t, scheduler.Resume("minutely"))
+	scheduler.TriggerBlockTasks(102, start.Add(3*time.Minute+5*time.Second))
+	require.Equal(t, []specy.Trigger{{Height: 102, Time: start.Add(3 * time.Minute)}}, recorder.takeTriggers())
+
+	// an update swaps the schedule in place
+	update := specy.NewTask("minutely", "", "", "", "new msgs", "new rule", "", specy.IntervalTypeTimeInterval, 30, start)
+	require.NoError(t, scheduler.Update(update))
+	updated, ok := scheduler.Get("minutely")
+	require.True(t, ok)
+	require.Equal(t, "name", updated.TaskName)
+	require.Equal(t, "new msgs", updated.Msgs)
+	require.Equal(t, "new rule", updated.RuleFile)
+	require.Equal(t, 30, updated.Condition.Interval)
+	scheduler.TriggerBlockTasks(103, start.Add(3*time.Minute+40*time.Second))
+	require.Equal(t, []specy.Trigger{{Height: 103, Time: start.Add(3*time.Minute + 30*time.Second)}}, recorder.takeTriggers())
+
+	// the execution history is kept
+	last, ok, err := store.LastExecution("minutely")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, start.Add(3*time.Minute+30*time.Second), last.UTC())
+
+	// invalid updates leave the task unchanged
+	update.Condition.Interval = 0
+	require.Error(t, scheduler.Update(update))
+	unchanged, _ := scheduler.Get("minutely")
+	require.Equal(t, updated, unchanged)
+	require.Error(t, scheduler.Update(specy.NewTask("unknown", "", "", "", "", "", "", specy.IntervalTypeEveryBlock, 0, time.Time{})))
+	require.Error(t, scheduler.Pause("unknown"))
+
+	// an update swaps the guard and the overlap setting as well
+	guarded := specy.NewTask("minutely", "", "", "", "new msgs", "new rule", "", specy.IntervalTypeTimeInterval, 30, start)
+	guarded.Guard = &specy.Guard{Type: specy.GuardTypeHeight, Op: specy.GuardOpLt, Value: "1000"}
+	guarded.SkipIfRunning = true
+	require.NoError(t, scheduler.Update(guarded))
+	updated, _ = scheduler.Get("minutely")
+	require.Equal(t, guarded.Guard, updated.Guard)
+	require.True(t, updated.SkipIfRunning)
+	// the guard cannot be checked without querier, the task is skipped
+	scheduler.TriggerBlockTasks(104, start.Add(4*time.Minute+10*time.Second))
+	require.Empty(t, recorder.takeTriggers())
+}
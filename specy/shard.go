@@ -0,0 +1,265 @@
+package specy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultShardVirtualNodes = 64
+	DefaultShardMemberTTL    = 15 * time.Second
+
+	shardMemberFileSuffix = ".member"
+)
+
+// ShardRing splits the tasks among the members of an executor fleet with a consistent-hash ring.
+// Every member is placed on the ring at several virtual nodes, and a task is owned by the member
+// of the first virtual node following the hash of the task. When members join or leave, only the
+// tasks of the virtual nodes they take or free move to another member.
+type ShardRing struct {
+	self         string
+	virtualNodes int
+
+	mu      sync.RWMutex
+	members []string
+	points  []uint64
+	owners  map[uint64]string
+}
+
+var _ Ownership = (*ShardRing)(nil)
+
+// NewShardRing returns the ring of the member self, with the given members.
+// self is added to the members if missing.
+func NewShardRing(self string, virtualNodes int, members []string) *ShardRing {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultShardVirtualNodes
+	}
+	r := &ShardRing{
+		self:         self,
+		virtualNodes: virtualNodes,
+	}
+	r.SetMembers(members)
+	return r
+}
+
+// SetMembers rebalances the ring over the given members, and reports whether the membership changed.
+func (r *ShardRing) SetMembers(members []string) bool {
+	members = normalizeMembers(append([]string{r.self}, members...))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if equalMembers(members, r.members) {
+		return false
+	}
+
+	points := make([]uint64, 0, len(members)*r.virtualNodes)
+	owners := make(map[uint64]string, len(members)*r.virtualNodes)
+	for _, member := range members {
+		for i := 0; i < r.virtualNodes; i++ {
+			point := ringHash(member + "#" + strconv.Itoa(i))
+			if _, ok := owners[point]; ok {
+				// on a collision the first member in order keeps the point, on every member alike
+				continue
+			}
+			points = append(points, point)
+			owners[point] = member
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+
+	r.members = members
+	r.points = points
+	r.owners = owners
+	return true
+}
+
+// Members returns the members of the ring, sorted.
+func (r *ShardRing) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.members...)
+}
+
+// Owner returns the member owning the task.
+func (r *ShardRing) Owner(taskHash string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hash := ringHash(taskHash)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+// Owns reports whether the task is owned by this member.
+func (r *ShardRing) Owns(taskHash string) bool {
+	return r.Owner(taskHash) == r.self
+}
+
+func ringHash(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+func normalizeMembers(members []string) []string {
+	seen := make(map[string]bool, len(members))
+	normalized := make([]string, 0, len(members))
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if member == "" || seen[member] {
+			continue
+		}
+		seen[member] = true
+		normalized = append(normalized, member)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func equalMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// shardMember is the content of the file a member heartbeats into the membership directory.
+type shardMember struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ShardMembership discovers the members of a fleet sharing a membership directory, e.g. on shared storage,
+// and rebalances a ShardRing as they join or leave. Each member renews its own file in the directory
+// every ttl/3; a member whose file expired or was removed on shutdown leaves the ring.
+// Members must keep their clocks in sync well within ttl.
+type ShardMembership struct {
+	log  *zap.Logger
+	dir  string
+	ring *ShardRing
+	ttl  time.Duration
+}
+
+// NewShardMembership returns the membership of the member of ring in the directory dir.
+func NewShardMembership(log *zap.Logger, dir string, ring *ShardRing, ttl time.Duration) *ShardMembership {
+	if ttl <= 0 {
+		ttl = DefaultShardMemberTTL
+	}
+	return &ShardMembership{
+		log:  log,
+		dir:  dir,
+		ring: ring,
+		ttl:  ttl,
+	}
+}
+
+// Run keeps the membership of this member alive and the ring up to date until ctx is done,
+// then leaves the fleet. onRebalance is called whenever the members of the ring changed.
+func (m *ShardMembership) Run(ctx context.Context, onRebalance func()) {
+	ticker := time.NewTicker(m.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Heartbeat(); err != nil {
+			m.log.Warn("Failed to renew shard membership", zap.String("dir", m.dir), zap.Error(err))
+		} else if changed, err := m.Refresh(); err != nil {
+			m.log.Warn("Failed to list shard members", zap.String("dir", m.dir), zap.Error(err))
+		} else if changed && onRebalance != nil {
+			onRebalance()
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := m.Leave(); err != nil {
+				m.log.Warn("Failed to leave shard membership", zap.String("dir", m.dir), zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Heartbeat renews the membership of this member.
+func (m *ShardMembership) Heartbeat() error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	bz, err := json.Marshal(shardMember{ID: m.ring.self, ExpiresAt: time.Now().Add(m.ttl)})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.memberPath(m.ring.self), bz)
+}
+
+// Refresh rebalances the ring over the live members, and reports whether the membership changed.
+func (m *ShardMembership) Refresh() (bool, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	var members []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), shardMemberFileSuffix) {
+			continue
+		}
+		bz, err := os.ReadFile(filepath.Join(m.dir, entry.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			// left in the meantime
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		var member shardMember
+		if err := json.Unmarshal(bz, &member); err != nil {
+			m.log.Warn("Ignoring malformed shard member", zap.String("file", entry.Name()), zap.Error(err))
+			continue
+		}
+		if now.Before(member.ExpiresAt) {
+			members = append(members, member.ID)
+		}
+	}
+
+	if !m.ring.SetMembers(members) {
+		return false, nil
+	}
+	m.log.Info("Shard members changed", zap.Strings("members", m.ring.Members()))
+	return true, nil
+}
+
+// Leave removes this member from the fleet, so that the other members take over its tasks right away.
+func (m *ShardMembership) Leave() error {
+	err := os.Remove(m.memberPath(m.ring.self))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (m *ShardMembership) memberPath(id string) string {
+	// member ids are free form, hash them into a valid file name
+	return filepath.Join(m.dir, fmt.Sprintf("%x", sha256.Sum256([]byte(id)))[:16]+shardMemberFileSuffix)
+}
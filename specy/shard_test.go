@@ -0,0 +1,188 @@
+package specy_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestShardRingSplitsAndRebalancesTasks(t *testing.T) {
+	members := []string{"executor-0", "executor-1", "executor-2"}
+	rings := make([]*specy.ShardRing, len(members))
+	for i, member := range members {
+		rings[i] = specy.NewShardRing(member, 0, members)
+	}
+
+	const tasks = 3000
+	owners := make(map[string]string, tasks)
+	counts := make(map[string]int)
+	for i := 0; i < tasks; i++ {
+		hash := fmt.Sprintf("task-%d", i)
+		owner := rings[0].Owner(hash)
+		owners[hash] = owner
+		counts[owner]++
+
+		// every member agrees on the owner, and exactly one owns the task
+		owning := 0
+		for _, ring := range rings {
+			require.Equal(t, owner, ring.Owner(hash))
+			if ring.Owns(hash) {
+				owning++
+			}
+		}
+		require.Equal(t, 1, owning)
+	}
+	for _, member := range members {
+		require.InDelta(t, tasks/len(members), counts[member], float64(tasks/len(members)/3), member)
+	}
+
+	// a joining member only takes tasks over, the other tasks stay put
+	require.True(t, rings[0].SetMembers(append(members, "executor-3")))
+	require.False(t, rings[0].SetMembers(append(members, "executor-3")))
+	moved := 0
+	for hash, owner := range owners {
+		if newOwner := rings[0].Owner(hash); newOwner != owner {
+			require.Equal(t, "executor-3", newOwner)
+			moved++
+		}
+	}
+	require.InDelta(t, tasks/4, moved, float64(tasks/12))
+
+	// a leaving member hands its tasks over, the other tasks stay put
+	require.True(t, rings[0].SetMembers([]string{"executor-0", "executor-2"}))
+	for hash, owner := range owners {
+		if owner != "executor-1" {
+			require.Equal(t, owner, rings[0].Owner(hash))
+		}
+	}
+}
+
+func TestShardMembershipDiscoversMembers(t *testing.T) {
+	const ttl = 300 * time.Millisecond
+	dir := t.TempDir()
+
+	ring0 := specy.NewShardRing("executor-0", 0, nil)
+	ring1 := specy.NewShardRing("executor-1", 0, nil)
+	member0 := specy.NewShardMembership(zap.NewNop(), dir, ring0, ttl)
+	member1 := specy.NewShardMembership(zap.NewNop(), dir, ring1, ttl)
+
+	require.NoError(t, member0.Heartbeat())
+	changed, err := member0.Refresh()
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, []string{"executor-0"}, ring0.Members())
+
+	// a member joins
+	require.NoError(t, member1.Heartbeat())
+	changed, err = member0.Refresh()
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, []string{"executor-0", "executor-1"}, ring0.Members())
+
+	// a member leaves on shutdown
+	require.NoError(t, member1.Leave())
+	changed, err = member0.Refresh()
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, []string{"executor-0"}, ring0.Members())
+
+	// a member stops heartbeating and expires
+	require.NoError(t, member1.Heartbeat())
+	_, err = member0.Refresh()
+	require.NoError(t, err)
+	require.Len(t, ring0.Members(), 2)
+	time.Sleep(ttl)
+	require.NoError(t, member0.Heartbeat())
+	changed, err = member0.Refresh()
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, []string{"executor-0"}, ring0.Members())
+}
+
+func TestSchedulerReportsOwnedTasks(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := specy.NewMetrics(registry)
+
+	ring := specy.NewShardRing("executor-0", 0, nil)
+	recorder := new(executionRecorder)
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorder.execute)
+	scheduler.SetMetrics(metrics)
+	scheduler.SetOwnership(ring)
+
+	var owned, foreign string
+	for i := 0; owned == "" || foreign == ""; i++ {
+		hash := fmt.Sprintf("task-%d", i)
+		task := specy.NewTask(hash, "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})
+		require.NoError(t, scheduler.Register(task))
+
+		// alone on the ring, the instance owns every task
+		require.Equal(t, float64(i+1), testutil.ToFloat64(metrics.OwnedTasks))
+
+		ring.SetMembers([]string{"executor-1"})
+		if ring.Owns(hash) && owned == "" {
+			owned = hash
+		} else if !ring.Owns(hash) && foreign == "" {
+			foreign = hash
+		}
+		ring.SetMembers(nil)
+	}
+	registered := len(scheduler.List())
+
+	ring.SetMembers([]string{"executor-1"})
+	scheduler.Rebalance()
+	require.Less(t, testutil.ToFloat64(metrics.OwnedTasks), float64(registered))
+
+	scheduler.TriggerBlockTasks(1, time.Time{})
+	executed := recorder.take()
+	require.Contains(t, executed, owned)
+	require.NotContains(t, executed, foreign)
+}
+
+func TestShardedDependentsRunWithTheirRoot(t *testing.T) {
+	members := []string{"executor-0", "executor-1"}
+	rings := make([]*specy.ShardRing, len(members))
+	for i, member := range members {
+		rings[i] = specy.NewShardRing(member, 0, members)
+	}
+
+	// a root task and a dependent task owned by different members by their own hash
+	root := "root"
+	var dependent string
+	for i := 0; dependent == ""; i++ {
+		if hash := fmt.Sprintf("dependent-%d", i); rings[0].Owner(hash) != rings[0].Owner(root) {
+			dependent = hash
+		}
+	}
+
+	recorders := make([]*executionRecorder, len(members))
+	schedulers := make([]*specy.Scheduler, len(members))
+	for i := range members {
+		recorders[i] = new(executionRecorder)
+		schedulers[i] = specy.NewScheduler(context.Background(), zap.NewNop(), nil, recorders[i].execute)
+		schedulers[i].SetOwnership(rings[i])
+		// the dependent may be registered before its root
+		require.NoError(t, schedulers[i].Register(dependentTask(dependent, root)))
+		require.NoError(t, schedulers[i].Register(specy.NewTask(root, "name", "creator", "connection-0", "msgs", "rule", "type", specy.IntervalTypeEveryBlock, 0, time.Time{})))
+	}
+
+	for _, scheduler := range schedulers {
+		scheduler.TriggerBlockTasks(1, time.Time{})
+	}
+	owner := 0
+	if rings[0].Owner(root) == members[1] {
+		owner = 1
+	}
+	require.Equal(t, []string{root, dependent}, recorders[owner].take())
+	require.Empty(t, recorders[1-owner].take())
+
+	status, ok := schedulers[owner].TaskStatus(dependent)
+	require.True(t, ok)
+	require.True(t, status.Owned)
+}
@@ -0,0 +1,102 @@
+package specy
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TaskStatus describes a registered task and its upcoming activation for operators.
+type TaskStatus struct {
+	Task *Task `json:"task"`
+	// Owned reports whether this instance executes the task.
+	Owned bool `json:"owned"`
+	// NextFireTime is the next activation of a time based task, nil if none is planned.
+	NextFireTime *time.Time `json:"next_fire_time,omitempty"`
+	// NextFireHeight is the next height a block based task runs at, zero if unknown or none is planned.
+	NextFireHeight int64 `json:"next_fire_height,omitempty"`
+	// LastRun is the last accounted activation of the task, nil if none.
+	LastRun *time.Time `json:"last_run,omitempty"`
+}
+
+// TaskStatuses returns the status of every registered task, ordered by task hash.
+func (s *Scheduler) TaskStatuses() []TaskStatus {
+	tasks := s.List()
+	statuses := make([]TaskStatus, len(tasks))
+	for i, task := range tasks {
+		statuses[i] = s.taskStatus(task)
+	}
+	return statuses
+}
+
+// TaskStatus returns the status of the registered task with the given hash.
+func (s *Scheduler) TaskStatus(taskHash string) (TaskStatus, bool) {
+	task, ok := s.Get(taskHash)
+	if !ok {
+		return TaskStatus{}, false
+	}
+	return s.taskStatus(task), true
+}
+
+func (s *Scheduler) taskStatus(task *Task) TaskStatus {
+	status := TaskStatus{
+		Task:  task,
+		Owned: s.owns(task.TaskHash),
+	}
+	if last := s.lastRun(task.TaskHash); !last.IsZero() {
+		status.LastRun = &last
+	}
+	if task.Paused {
+		return status
+	}
+
+	s.mu.Lock()
+	height, now := s.height, s.now()
+	s.mu.Unlock()
+
+	switch task.Condition.IntervalType {
+	case IntervalTypeTimeInterval, IntervalTypeCron:
+		schedule, err := timeSchedule(task)
+		if err != nil {
+			break
+		}
+		if now.IsZero() {
+			// no block processed yet on the chain clock
+			now = time.Now()
+		}
+		if next := schedule.Next(now); !next.IsZero() {
+			status.NextFireTime = &next
+		}
+
+	case IntervalTypeEveryBlock, IntervalTypeEveryNBlocks:
+		if height > 0 {
+			status.NextFireHeight = task.Condition.nextHeight(height)
+		}
+	}
+	return status
+}
+
+// TriggerNow executes the task right away on this instance, whichever instance owns it
+// and whether it is paused or not.
+func (s *Scheduler) TriggerNow(taskHash string) error {
+	s.mu.Lock()
+	task, ok := s.tasks[taskHash]
+	height := s.height
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown task %s", taskHash)
+	}
+
+	s.log.Info("Triggering task on demand", zap.String("task_hash", taskHash))
+	s.submit(task, Trigger{Height: height, Time: time.Now().UTC()})
+	return nil
+}
+
+// Drain drops the task executions waiting for a worker, and returns how many were dropped.
+func (s *Scheduler) Drain() int {
+	if s.pool == nil {
+		return 0
+	}
+	return s.pool.Drain()
+}
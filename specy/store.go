@@ -0,0 +1,334 @@
+package specy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+)
+
+const (
+	// taskStoreDir is the directory, relative to the relayer home, holding the scheduler database.
+	taskStoreDir  = "specy"
+	taskStoreName = "tasks"
+)
+
+var (
+	taskPrefix          = []byte("task/")
+	lastExecutionPrefix = []byte("last_exec/")
+	lastHeightKey       = []byte("last_height")
+	executionPrefix     = []byte("exec/")
+	completedAtPrefix   = []byte("exec_at/")
+	historyPrefix       = []byte("history/")
+)
+
+// TaskStore persists registered tasks and scheduler progress so that
+// the scheduler can rebuild its state after a restart.
+type TaskStore struct {
+	db dbm.DB
+}
+
+// NewTaskStore returns a TaskStore backed by the given database.
+func NewTaskStore(db dbm.DB) *TaskStore {
+	return &TaskStore{db: db}
+}
+
+// OpenTaskStore opens (or creates) the on-disk task store under the given relayer home directory.
+func OpenTaskStore(homePath string) (*TaskStore, error) {
+	dir := filepath.Join(homePath, taskStoreDir)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create task store directory %s: %w", dir, err)
+	}
+	db, err := dbm.NewGoLevelDB(taskStoreName, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open task store in %s: %w", dir, err)
+	}
+	return NewTaskStore(db), nil
+}
+
+// Close closes the underlying database.
+func (ts *TaskStore) Close() error {
+	return ts.db.Close()
+}
+
+// SaveTask persists the task, replacing any previous version with the same hash.
+func (ts *TaskStore) SaveTask(task *Task) error {
+	bz, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task %s: %w", task.TaskHash, err)
+	}
+	return ts.db.SetSync(taskKey(task.TaskHash), bz)
+}
+
+// DeleteTask removes the task and its execution bookkeeping from the store.
+// Its execution history is kept until pruned, see PruneExecutionHistory.
+func (ts *TaskStore) DeleteTask(taskHash string) error {
+	batch := ts.db.NewBatch()
+	defer batch.Close()
+	if err := batch.Delete(taskKey(taskHash)); err != nil {
+		return err
+	}
+	if err := batch.Delete(lastExecutionKey(taskHash)); err != nil {
+		return err
+	}
+
+	// the completed executions are deleted along with their entries of the completion time index
+	prefix := executionKey(taskHash, "")
+	err := ts.deletePrefix(batch, prefix, func(key, value []byte) bool {
+		var completedAt time.Time
+		if completedAt.UnmarshalBinary(value) == nil {
+			_ = batch.Delete(completedAtKey(completedAt, append(append([]byte{}, prefix...), key...)))
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return batch.WriteSync()
+}
+
+// deletePrefix adds the deletion of the entries under prefix selected by del to the batch.
+func (ts *TaskStore) deletePrefix(batch dbm.Batch, prefix []byte, del func(key, value []byte) bool) error {
+	iter, err := dbm.NewPrefixDB(ts.db, prefix).Iterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		if !del(iter.Key(), iter.Value()) {
+			continue
+		}
+		if err := batch.Delete(append(append([]byte{}, prefix...), iter.Key()...)); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// Tasks returns all persisted tasks ordered by task hash.
+func (ts *TaskStore) Tasks() ([]*Task, error) {
+	iter, err := dbm.NewPrefixDB(ts.db, taskPrefix).Iterator(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var tasks []*Task
+	for ; iter.Valid(); iter.Next() {
+		task := new(Task)
+		if err := json.Unmarshal(iter.Value(), task); err != nil {
+			return nil, fmt.Errorf("failed to decode task %s: %w", iter.Key(), err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, iter.Error()
+}
+
+// SetLastExecution records the time the task was last executed.
+func (ts *TaskStore) SetLastExecution(taskHash string, t time.Time) error {
+	bz, err := t.UTC().MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return ts.db.Set(lastExecutionKey(taskHash), bz)
+}
+
+// LastExecution returns the time the task was last executed.
+// The boolean is false if the task has never been executed.
+func (ts *TaskStore) LastExecution(taskHash string) (time.Time, bool, error) {
+	bz, err := ts.db.Get(lastExecutionKey(taskHash))
+	if err != nil || bz == nil {
+		return time.Time{}, false, err
+	}
+	var t time.Time
+	if err := t.UnmarshalBinary(bz); err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// SetExecutionCompleted records that the execution of the task with the given ID completed at t.
+// The execution is indexed by its completion time as well, for PruneExecutionLedger.
+func (ts *TaskStore) SetExecutionCompleted(taskHash, executionID string, t time.Time) error {
+	bz, err := t.UTC().MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	batch := ts.db.NewBatch()
+	defer batch.Close()
+	key := executionKey(taskHash, executionID)
+	if err := batch.Set(key, bz); err != nil {
+		return err
+	}
+	if err := batch.Set(completedAtKey(t, key), key); err != nil {
+		return err
+	}
+	return batch.WriteSync()
+}
+
+// ExecutionCompleted reports whether the execution of the task with the given ID completed.
+func (ts *TaskStore) ExecutionCompleted(taskHash, executionID string) (bool, error) {
+	return ts.db.Has(executionKey(taskHash, executionID))
+}
+
+// AppendExecutionRecord adds the record to the execution history of its task.
+func (ts *TaskStore) AppendExecutionRecord(record *ExecutionRecord) error {
+	bz, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode execution record %s: %w", record.ExecutionID, err)
+	}
+	return ts.db.Set(historyKey(record.TaskHash, record.FinishedAt, record.ExecutionID), bz)
+}
+
+// ExecutionHistory returns the execution records of the task, most recent first, up to limit records.
+// A non positive limit returns every record.
+func (ts *TaskStore) ExecutionHistory(taskHash string, limit int) ([]*ExecutionRecord, error) {
+	iter, err := dbm.NewPrefixDB(ts.db, historyTaskPrefix(taskHash)).ReverseIterator(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var records []*ExecutionRecord
+	for ; iter.Valid() && (limit <= 0 || len(records) < limit); iter.Next() {
+		record := new(ExecutionRecord)
+		if err := json.Unmarshal(iter.Value(), record); err != nil {
+			return nil, fmt.Errorf("failed to decode execution record of task %s: %w", taskHash, err)
+		}
+		records = append(records, record)
+	}
+	return records, iter.Error()
+}
+
+// PruneExecutionHistory deletes the execution records of the task beyond the maxRecords most recent ones
+// or finished before the given time.
+func (ts *TaskStore) PruneExecutionHistory(taskHash string, maxRecords int, before time.Time) error {
+	batch := ts.db.NewBatch()
+	defer batch.Close()
+
+	iter, err := dbm.NewPrefixDB(ts.db, historyTaskPrefix(taskHash)).ReverseIterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	kept := 0
+	for ; iter.Valid(); iter.Next() {
+		finishedAt := time.Unix(0, int64(binary.BigEndian.Uint64(iter.Key()[:8])))
+		if kept < maxRecords && !finishedAt.Before(before) {
+			kept++
+			continue
+		}
+		if err := batch.Delete(append(historyTaskPrefix(taskHash), iter.Key()...)); err != nil {
+			return err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// ExecutionHistoryTasks returns the hashes of the tasks with an execution history, registered or not.
+func (ts *TaskStore) ExecutionHistoryTasks() ([]string, error) {
+	var hashes []string
+	var start []byte
+	for {
+		iter, err := dbm.NewPrefixDB(ts.db, historyPrefix).Iterator(start, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !iter.Valid() {
+			err := iter.Error()
+			iter.Close()
+			return hashes, err
+		}
+		key := iter.Key()
+		iter.Close()
+
+		// the records of a task are skipped at once, following its hash and separator
+		i := bytes.IndexByte(key, '/')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid execution history key %q", key)
+		}
+		hashes = append(hashes, string(key[:i]))
+		start = append(append([]byte{}, key[:i]...), '/'+1)
+	}
+}
+
+// PruneExecutionLedger deletes the completed executions of every task recorded before the given time.
+// The ledger is range scanned by completion time, so only the deleted executions are visited.
+func (ts *TaskStore) PruneExecutionLedger(before time.Time) error {
+	batch := ts.db.NewBatch()
+	defer batch.Close()
+
+	end := binary.BigEndian.AppendUint64(nil, uint64(before.UnixNano()))
+	iter, err := dbm.NewPrefixDB(ts.db, completedAtPrefix).Iterator(nil, end)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		if err := batch.Delete(append(append([]byte{}, completedAtPrefix...), iter.Key()...)); err != nil {
+			return err
+		}
+		if err := batch.Delete(append([]byte{}, iter.Value()...)); err != nil {
+			return err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// SetLastHeight records the last chain height processed by the scheduler.
+func (ts *TaskStore) SetLastHeight(height int64) error {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, uint64(height))
+	return ts.db.Set(lastHeightKey, bz)
+}
+
+// LastHeight returns the last chain height processed by the scheduler, or 0 if none was recorded.
+func (ts *TaskStore) LastHeight() (int64, error) {
+	bz, err := ts.db.Get(lastHeightKey)
+	if err != nil || len(bz) != 8 {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(bz)), nil
+}
+
+func taskKey(taskHash string) []byte {
+	return append(append([]byte{}, taskPrefix...), taskHash...)
+}
+
+func lastExecutionKey(taskHash string) []byte {
+	return append(append([]byte{}, lastExecutionPrefix...), taskHash...)
+}
+
+func executionKey(taskHash, executionID string) []byte {
+	return []byte(string(executionPrefix) + taskHash + "/" + executionID)
+}
+
+func historyTaskPrefix(taskHash string) []byte {
+	return []byte(string(historyPrefix) + taskHash + "/")
+}
+
+// completedAtKey orders the ledger entries of completed executions by the time they completed.
+func completedAtKey(completedAt time.Time, executionKey []byte) []byte {
+	key := append([]byte{}, completedAtPrefix...)
+	key = binary.BigEndian.AppendUint64(key, uint64(completedAt.UnixNano()))
+	return append(key, executionKey...)
+}
+
+// historyKey orders the execution records of a task by the time they finished.
+func historyKey(taskHash string, finishedAt time.Time, executionID string) []byte {
+	key := historyTaskPrefix(taskHash)
+	key = binary.BigEndian.AppendUint64(key, uint64(finishedAt.UnixNano()))
+	return append(key, executionID...)
+}
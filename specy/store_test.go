@@ -0,0 +1,63 @@
+package specy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	"github.com/cosmos/relayer/v2/specy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTaskStoreRoundTrip(t *testing.T) {
+	store := specy.NewTaskStore(dbm.NewMemDB())
+
+	startTime := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+	task := specy.NewTask("hash1", "rewards", "creator", "connection-0", "msgs", "rule", "SetRewards", "time_interval", 3600, startTime)
+	require.NoError(t, store.SaveTask(task))
+	require.NoError(t, store.SaveTask(specy.NewTask("hash2", "snapshot", "creator", "connection-0", "msgs", "rule", "Snapshot", "every_block", 0, time.Time{})))
+
+	tasks, err := store.Tasks()
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Equal(t, task, tasks[0])
+	require.Equal(t, "hash2", tasks[1].TaskHash)
+
+	_, ok, err := store.LastExecution("hash1")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	executedAt := startTime.Add(time.Hour)
+	require.NoError(t, store.SetLastExecution("hash1", executedAt))
+	lastExecution, ok, err := store.LastExecution("hash1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, executedAt.Equal(lastExecution))
+
+	require.NoError(t, store.DeleteTask("hash1"))
+	tasks, err = store.Tasks()
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	_, ok, err = store.LastExecution("hash1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestTaskStoreLastHeight(t *testing.T) {
+	store := specy.NewTaskStore(dbm.NewMemDB())
+
+	height, err := store.LastHeight()
+	require.NoError(t, err)
+	require.Zero(t, height)
+
+	require.NoError(t, store.SetLastHeight(1234))
+	height, err = store.LastHeight()
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), height)
+
+	// a scheduler restarted on the store resumes from the recorded height
+	scheduler := specy.NewScheduler(context.Background(), zap.NewNop(), store, nil)
+	require.Equal(t, int64(1234), scheduler.LastProcessedHeight())
+}
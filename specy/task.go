@@ -0,0 +1,198 @@
+package specy
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	IntervalTypeTimeInterval = "time_interval"
+	IntervalTypeEveryBlock   = "every_block"
+	IntervalTypeEveryNBlocks = "every_n_blocks"
+	IntervalTypeCron         = "cron"
+	IntervalTypeOnEvent      = "on_event"
+	// IntervalTypeDependent tasks run once every task they depend on succeeded for the same slot.
+	IntervalTypeDependent = "depends_on"
+)
+
+type Task struct {
+	TaskHash     string `json:"task_hash"`
+	TaskName     string `json:"task_name"`
+	Creator      string `json:"creator"`
+	ConnectionId string `json:"connection_id"`
+	Msgs         string `json:"msgs"`
+	RuleFile     string `json:"rule_file"`
+	TaskType     string `json:"task_type"`
+
+	// SkipIfRunning drops a triggered execution while a previous execution of the task is still queued or running.
+	SkipIfRunning bool `json:"skip_if_running,omitempty"`
+
+	// DependsOn holds the hashes of the tasks that must succeed before a depends_on task runs.
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Guard is an optional on-chain condition that must hold for the task to be executed.
+	Guard *Guard `json:"guard,omitempty"`
+
+	// Paused tasks stay registered without being triggered until resumed.
+	Paused bool `json:"paused,omitempty"`
+
+	Condition Condition `json:"condition"`
+}
+
+type Condition struct {
+	IntervalType string `json:"interval_type"`
+	Interval     int    `json:"interval"`
+
+	StartTime time.Time `json:"start_time"`
+
+	// EndTime optionally stops time based tasks after the given time. Zero means unbounded.
+	EndTime time.Time `json:"end_time"`
+
+	// CronExpression is the schedule of cron tasks.
+	CronExpression string `json:"cron_expression,omitempty"`
+
+	// StartHeight and EndHeight optionally bound the heights at which block based tasks run.
+	// Zero means unbounded.
+	StartHeight int64 `json:"start_height,omitempty"`
+	EndHeight   int64 `json:"end_height,omitempty"`
+
+	// EventType and EventPredicates select the chain events triggering on_event tasks.
+	EventType       string           `json:"event_type,omitempty"`
+	EventPredicates []EventPredicate `json:"event_predicates,omitempty"`
+
+	// MisfirePolicy and MaxMissedRuns override the scheduler misfire policy for time based tasks.
+	MisfirePolicy string `json:"misfire_policy,omitempty"`
+	MaxMissedRuns int    `json:"max_missed_runs,omitempty"`
+}
+
+// inHeightWindow reports whether the height is within the start and end heights of the condition.
+func (c Condition) inHeightWindow(height int64) bool {
+	if c.StartHeight > 0 && height < c.StartHeight {
+		return false
+	}
+	return c.EndHeight <= 0 || height <= c.EndHeight
+}
+
+// dueAtHeight reports whether a block based task with this condition runs at the given height.
+// every_n_blocks tasks run every Interval blocks counted from StartHeight.
+func (c Condition) dueAtHeight(height int64) bool {
+	if !c.inHeightWindow(height) {
+		return false
+	}
+	if c.IntervalType == IntervalTypeEveryNBlocks {
+		return (height-c.StartHeight)%int64(c.Interval) == 0
+	}
+	return true
+}
+
+// nextHeight returns the first height after the given one at which a block based task with this condition
+// runs, or zero if the task does not run after that height.
+func (c Condition) nextHeight(height int64) int64 {
+	next := height + 1
+	if next < c.StartHeight {
+		next = c.StartHeight
+	}
+	if c.IntervalType == IntervalTypeEveryNBlocks {
+		if offset := (next - c.StartHeight) % int64(c.Interval); offset != 0 {
+			next += int64(c.Interval) - offset
+		}
+	}
+	if c.EndHeight > 0 && next > c.EndHeight {
+		return 0
+	}
+	return next
+}
+
+func NewTask(taskHash string, taskName string, creator string, connectionId string, msgs string, ruleFile string, taskType string, intervalType string, interval int, startTime time.Time) *Task {
+
+	return &Task{
+		TaskHash:     taskHash,
+		TaskName:     taskName,
+		Creator:      creator,
+		ConnectionId: connectionId,
+		Msgs:         msgs,
+		RuleFile:     ruleFile,
+		TaskType:     taskType,
+
+		Condition: Condition{
+			IntervalType: intervalType,
+			Interval:     interval,
+			StartTime:    startTime,
+		},
+	}
+}
+
+// Updated returns a copy of the task with the condition, rule file, messages, guard and overlap setting
+// of update, along with the tasks it depends on which come with its condition.
+func (t *Task) Updated(update *Task) *Task {
+	updated := *t
+	updated.Condition = update.Condition
+	updated.RuleFile = update.RuleFile
+	updated.Msgs = update.Msgs
+	updated.DependsOn = update.DependsOn
+	updated.Guard = update.Guard
+	updated.SkipIfRunning = update.SkipIfRunning
+	return &updated
+}
+
+// validateTask checks the condition and the guard of the task.
+func validateTask(task *Task) error {
+	if err := ValidateCondition(task.Condition); err != nil {
+		return err
+	}
+	if task.Guard != nil {
+		return task.Guard.Validate()
+	}
+	return nil
+}
+
+// ValidateCondition checks that the condition describes a schedule the scheduler can run.
+func ValidateCondition(condition Condition) error {
+	if condition.StartHeight < 0 || condition.EndHeight < 0 {
+		return fmt.Errorf("negative height window [%d, %d]", condition.StartHeight, condition.EndHeight)
+	}
+	if condition.EndHeight > 0 && condition.EndHeight < condition.StartHeight {
+		return fmt.Errorf("end height %d is before start height %d", condition.EndHeight, condition.StartHeight)
+	}
+	if !condition.EndTime.IsZero() && !condition.EndTime.After(condition.StartTime) {
+		return fmt.Errorf("end time %s is not after start time %s", condition.EndTime, condition.StartTime)
+	}
+	if err := ValidateMisfirePolicy(condition.MisfirePolicy); err != nil {
+		return err
+	}
+	if condition.MaxMissedRuns < 0 {
+		return fmt.Errorf("max missed runs must not be negative, got %d", condition.MaxMissedRuns)
+	}
+
+	switch condition.IntervalType {
+	case IntervalTypeTimeInterval:
+		if condition.Interval <= 0 {
+			return fmt.Errorf("time_interval interval must be positive, got %d", condition.Interval)
+		}
+		return nil
+	case IntervalTypeEveryBlock:
+		return nil
+	case IntervalTypeEveryNBlocks:
+		if condition.Interval <= 0 {
+			return fmt.Errorf("every_n_blocks interval must be positive, got %d", condition.Interval)
+		}
+		return nil
+	case IntervalTypeCron:
+		_, err := ParseCronSchedule(condition.CronExpression)
+		return err
+	case IntervalTypeDependent:
+		return nil
+	case IntervalTypeOnEvent:
+		if condition.EventType == "" {
+			return fmt.Errorf("on_event task without event type")
+		}
+		for _, predicate := range condition.EventPredicates {
+			if err := validateEventPredicate(predicate); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported task interval type %q", condition.IntervalType)
+	}
+}